@@ -0,0 +1,83 @@
+package geecache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+)
+
+// defaultAdminKeys is the number of keys /keys lists when n is not given.
+const defaultAdminKeys = 20
+
+// NewAdminHandler returns a handler for diagnosing the groups of the
+// default registry in production. See Registry.AdminHandler.
+func NewAdminHandler(withPprof bool) http.Handler {
+	return defaultRegistry.AdminHandler(withPprof)
+}
+
+// AdminHandler returns a handler for diagnosing the groups of r in
+// production. It serves, relative to where it is mounted:
+//
+//	GET /stats            per-group CacheStats
+//	GET /keys?group=g&n=N the N most recently used keys of group g
+//	GET /config           per-group GroupConfig
+//	GET /bench?duration=d SelfBenchmark for d, one run at a time
+//	/debug/pprof/...      net/http/pprof, when withPprof is set
+//
+// Keys and profiles can be sensitive: mount the handler on an internal
+// listener, not next to the peer pool. To mount it under a prefix, wrap
+// it in http.StripPrefix.
+func (r *Registry) AdminHandler(withPprof bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", r.serveAdminStats)
+	mux.HandleFunc("/keys", r.serveAdminKeys)
+	mux.HandleFunc("/config", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, r.configs())
+	})
+	mux.HandleFunc("/bench", serveBench)
+	if withPprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+	return mux
+}
+
+func (r *Registry) serveAdminStats(w http.ResponseWriter, _ *http.Request) {
+	stats := make(map[string]CacheStats)
+	for name, g := range r.snapshot() {
+		stats[name] = g.Stats()
+	}
+	writeJSON(w, stats)
+}
+
+func (r *Registry) serveAdminKeys(w http.ResponseWriter, req *http.Request) {
+	name := req.URL.Query().Get("group")
+	g := r.GetGroup(name)
+	if g == nil {
+		writeError(w, "no such group: "+name, http.StatusNotFound)
+		return
+	}
+	n := defaultAdminKeys
+	if s := req.URL.Query().Get("n"); s != "" {
+		var err error
+		if n, err = strconv.Atoi(s); err != nil || n <= 0 {
+			writeError(w, "bad n: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+	keys := g.mainCache.hotKeys(n)
+	if keys == nil {
+		keys = []string{}
+	}
+	writeJSON(w, keys)
+}
+
+// writeJSON replies with v encoded as JSON.
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
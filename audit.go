@@ -0,0 +1,157 @@
+package geecache
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEvent describes one mutation applied through the pool: a peer
+// delete or update, a membership change, or a local change of the peer list.
+type AuditEvent struct {
+	Time   time.Time `json:"time"`
+	Actor  string    `json:"actor"` // sending peer, remote address, or "local"
+	Op     string    `json:"op"`    // delete, incr, append, cas, leave or set-peers
+	Group  string    `json:"group,omitempty"`
+	Key    string    `json:"key,omitempty"`
+	Detail string    `json:"detail,omitempty"` // query string or new peer list
+	Status int       `json:"status,omitempty"` // HTTP status of peer requests
+}
+
+// AuditSink records audit events. Audit is called synchronously on the
+// request path after the mutation, so it must be safe for concurrent use
+// and should not block.
+type AuditSink interface {
+	Audit(e AuditEvent)
+}
+
+// AuditFunc adapts a function to AuditSink.
+type AuditFunc func(e AuditEvent)
+
+// Audit implements AuditSink.
+func (f AuditFunc) Audit(e AuditEvent) { f(e) }
+
+// WithAuditSink records every mutation the pool applies to s.
+func WithAuditSink(s AuditSink) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.audit = s
+	}
+}
+
+// LogAuditSink writes events to l, or the standard logger if l is nil.
+func LogAuditSink(l *log.Logger) AuditSink {
+	if l == nil {
+		l = log.Default()
+	}
+	return AuditFunc(func(e AuditEvent) {
+		l.Printf("[Audit] actor=%s op=%s group=%s key=%q detail=%q status=%d",
+			e.Actor, e.Op, e.Group, e.Key, e.Detail, e.Status)
+	})
+}
+
+// JSONAuditSink writes one JSON object per line to w, e.g. an append-only file.
+func JSONAuditSink(w io.Writer) AuditSink {
+	var mu sync.Mutex
+	enc := json.NewEncoder(w)
+	return AuditFunc(func(e AuditEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := enc.Encode(e); err != nil {
+			slog.Warn("writing audit event", "err", err)
+		}
+	})
+}
+
+// webhookQueue bounds the events waiting to be posted by a webhook sink.
+const webhookQueue = 1024
+
+// WebhookAuditSink POSTs each event as JSON to url from a background
+// goroutine, so a slow endpoint never delays peer requests. Events are
+// dropped and logged when the queue is full. client nil means
+// http.DefaultClient.
+func WebhookAuditSink(url string, client *http.Client) AuditSink {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	events := make(chan AuditEvent, webhookQueue)
+	go func() {
+		for e := range events {
+			body, _ := json.Marshal(e)
+			res, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				slog.Warn("posting audit event", "err", err)
+				continue
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+	}()
+	return AuditFunc(func(e AuditEvent) {
+		select {
+		case events <- e:
+		default:
+			slog.Warn("audit webhook queue full, dropping event", "op", e.Op, "group", e.Group, "key", e.Key)
+		}
+	})
+}
+
+// auditWriter captures the status written by a handler.
+type auditWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *auditWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// auditRequest wraps w when r is a mutation and the pool has a sink.
+// The returned done func records the event once the request is served.
+func (p *HTTPPool) auditRequest(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, func()) {
+	if p.audit == nil {
+		return w, func() {}
+	}
+	op := r.URL.Query().Get("op")
+	switch {
+	case r.Method == http.MethodDelete:
+		op = "delete"
+	case r.Method != http.MethodPost || op == "getmulti" || op == "":
+		return w, func() {}
+	}
+	aw := &auditWriter{ResponseWriter: w, status: http.StatusOK}
+	return aw, func() {
+		e := AuditEvent{
+			Time:   time.Now(),
+			Actor:  r.Header.Get(peerHeader),
+			Op:     op,
+			Detail: r.URL.RawQuery,
+			Status: aw.status,
+		}
+		if e.Actor == "" {
+			e.Actor = r.RemoteAddr
+		}
+		if rest := strings.TrimPrefix(r.URL.Path, p.basePath); rest != r.URL.Path {
+			e.Group, e.Key, _ = strings.Cut(rest, "/")
+		}
+		p.audit.Audit(e)
+	}
+}
+
+// auditPeers records a local change of the peer list.
+func (p *HTTPPool) auditPeers(peers []string) {
+	if p.audit != nil {
+		p.audit.Audit(AuditEvent{
+			Time:   time.Now(),
+			Actor:  "local",
+			Op:     "set-peers",
+			Detail: strings.Join(peers, ","),
+		})
+	}
+}
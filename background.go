@@ -0,0 +1,122 @@
+package geecache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultBackgroundLimit 每个Group同时运行的一次性后台任务默认上限
+const defaultBackgroundLimit = 16
+
+// background 管理一个Group拥有的后台goroutine（清理、刷新、异步写入等）
+// 设计目标：
+//  1. 生命周期独立：每个Group各自持有，Group.Close只停止自己的任务
+//  2. 资源有界：一次性任务受并发上限约束，超限时丢弃而不是排队堆积，
+//     一个配置失误的Group不会耗尽其他Group的资源
+//  3. 可观测：运行中的goroutine数与丢弃数计入CacheStats
+type background struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex // 使启动任务时的关闭检查与wg.Add和close的cancel互斥
+	wg     sync.WaitGroup
+	sem    chan struct{} // 一次性任务的并发配额
+
+	running atomic.Int64 // 运行中的goroutine数（含周期任务）
+	dropped atomic.Int64 // 因超限或已关闭被丢弃的任务数
+}
+
+func newBackground(limit int) *background {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &background{ctx: ctx, cancel: cancel, sem: make(chan struct{}, limit)}
+}
+
+// goTask 在配额内启动一次性后台任务，返回是否已启动
+// 配额用尽或Group已关闭时任务被丢弃；fn应在ctx取消时尽快返回
+func (b *background) goTask(fn func(ctx context.Context)) bool {
+	select {
+	case b.sem <- struct{}{}:
+	default:
+		b.dropped.Add(1)
+		return false
+	}
+	started := b.start(func() {
+		defer func() { <-b.sem }()
+		fn(b.ctx)
+	})
+	if !started {
+		<-b.sem
+		b.dropped.Add(1)
+	}
+	return started
+}
+
+// loop 启动周期任务，每隔interval执行一次fn，直到Group关闭
+// 周期任务不占用一次性任务的配额
+func (b *background) loop(interval time.Duration, fn func(ctx context.Context)) {
+	b.start(func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				fn(b.ctx)
+			case <-b.ctx.Done():
+				return
+			}
+		}
+	})
+}
+
+// start 在新goroutine中运行fn，Group已关闭时不启动并返回false
+// 关闭检查与wg.Add在mu内一并完成，close返回后不会再有任务启动
+func (b *background) start(fn func()) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed() {
+		return false
+	}
+	b.wg.Add(1)
+	b.running.Add(1)
+	go func() {
+		defer b.wg.Done()
+		defer b.running.Add(-1)
+		fn()
+	}()
+	return true
+}
+
+// closed 报告Group是否已关闭
+func (b *background) closed() bool {
+	return b.ctx.Err() != nil
+}
+
+// close 取消所有后台任务并等待其退出
+func (b *background) close() {
+	b.mu.Lock()
+	b.cancel()
+	b.mu.Unlock()
+	b.wg.Wait()
+}
+
+// WithBackgroundLimit 设置Group同时运行的一次性后台任务上限
+// n<=0 时保持默认值
+func WithBackgroundLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.backgroundLimit = n
+		}
+	}
+}
+
+// Close 停止Group拥有的所有后台任务并等待其退出
+// 配置了WithNegativeCacheFile时随后写回负缓存，返回写入错误。
+// Close之后Group仍可读写，但不再启动新的后台任务
+func (g *Group) Close() error {
+	g.bg.close()
+	if g.negCacheFile != "" {
+		return g.saveNegativeCacheFile()
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BatchGetter 批量数据加载器接口（可选）
+// Getter 同时实现该接口时，GetMulti 对本节点负责的未命中key只回源一次，
+// 否则逐个key加载（仍经过singleflight去重）
+//
+// 返回值约定：
+//   - 找到的key放入返回的map
+//   - 单个key失败时返回 BatchError 记录该key的错误，其余结果照常返回
+//   - 既不在map也不在BatchError中的key视为明确不存在，会被负缓存
+//   - 返回其他类型的错误表示整批失败
+type BatchGetter interface {
+	GetMulti(ctx context.Context, keys []string) (map[string][]byte, error)
+}
+
+// BatchError 批量操作中部分key失败时返回，记录每个失败key的错误
+// 其余key的结果仍然有效
+type BatchError map[string]error
+
+func (e BatchError) Error() string {
+	keys := make([]string, 0, len(e))
+	for k := range e {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	msgs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		msgs = append(msgs, k+": "+e[k].Error())
+	}
+	return fmt.Sprintf("geecache: %d keys failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// GetMulti 批量获取多个key，返回命中或加载成功的值
+// 等价于 GetMultiContext(context.Background(), keys)
+func (g *Group) GetMulti(keys []string) (map[string]ByteView, error) {
+	return g.GetMultiContext(context.Background(), keys)
+}
+
+// GetMultiContext 批量获取多个key
+// 执行流程：
+//  1. 查询本地mainCache/hotCache，收集未命中的key
+//  2. 按所属节点分组，每个远端节点只发送一次批量请求
+//  3. 本节点负责的key通过BatchGetter批量回源
+//  4. 各组并发执行
+//
+// 部分失败：成功的值照常返回并缓存，失败的key汇总为 BatchError 返回；
+// 不存在的key既不在结果中也不在错误中
+//
+// 设计目标：调用方一次需要几十个key时，避免逐个key的网络往返
+func (g *Group) GetMultiContext(ctx context.Context, keys []string) (map[string]ByteView, error) {
+	values := make(map[string]ByteView, len(keys))
+	var (
+		local  []string
+		remote = make(map[PeerGetter][]string)
+		seen   = make(map[string]bool, len(keys))
+		bypass = g.bypassing() // 直连数据源模式下不查缓存也不转发，全部直接回源
+	)
+	for _, key := range keys {
+		if key == "" {
+			return nil, fmt.Errorf("key is required")
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		v, ok := g.lookupCache(key)
+		g.recordGet(key, ok)
+		if ok {
+			values[key] = v
+			continue
+		}
+		if g.peers != nil && !bypass {
+			if peer, ok := g.pickPeer(key); ok {
+				remote[peer] = append(remote[peer], key)
+				continue
+			}
+		}
+		local = append(local, key)
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs = make(BatchError)
+	)
+	collect := func(found map[string]ByteView, failed BatchError) {
+		mu.Lock()
+		defer mu.Unlock()
+		for k, v := range found {
+			values[k] = v
+		}
+		for k, err := range failed {
+			errs[k] = err
+		}
+	}
+
+	for peer, keys := range remote {
+		wg.Add(1)
+		go func(peer PeerGetter, keys []string) {
+			defer wg.Done()
+			collect(g.getMultiFromPeer(ctx, peer, keys))
+		}(peer, keys)
+	}
+	if len(local) > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collect(g.getMultiLocally(ctx, local))
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return values, errs
+	}
+	return values, nil
+}
+
+// getMultiFromPeer 向一个远端节点发送批量请求
+// 与getFromPeer一致：每次请求受WithPeerTimeout约束，整批的瞬时失败按
+// WithPeerRetries重试（批量请求不区分key，使用Group级的重试次数），
+// 结果按概率写入hotCache（期间被删除的key不写入）；整批失败时每个key都记为失败
+func (g *Group) getMultiFromPeer(ctx context.Context, peer PeerGetter, keys []string) (map[string]ByteView, BatchError) {
+	g.stats.loads.Add(int64(len(keys)))
+	loads := make(map[string]*inflightLoad, len(keys))
+	for _, k := range keys {
+		loads[k] = g.inflight.begin(k)
+	}
+	var found map[string][]byte
+	_, err := g.withPeerRetries(ctx, g.peerRetries, g.peerBackoff, func() ([]byte, error) {
+		return g.withPeerTimeout(ctx, func(ctx context.Context) ([]byte, error) {
+			var err error
+			found, err = peer.GetMulti(ctx, g.name, keys)
+			return nil, err
+		})
+	})
+	failed := splitBatchError(keys, err)
+	g.stats.peerErrors.Add(int64(len(failed)))
+	g.stats.peerLoads.Add(int64(len(found)))
+
+	values := make(map[string]ByteView, len(found))
+	for _, k := range keys {
+		cacheable := g.cacheable(loads[k])
+		b, ok := found[k]
+		if !ok {
+			continue
+		}
+		value := ByteView{b: b, src: SourcePeer}
+		if cacheable && g.rand.Intn(hotCachePopulateOdds) == 0 {
+			g.addHot(k, value)
+		}
+		values[k] = value
+	}
+	return values, failed
+}
+
+// getMultiLocally 获取本节点负责的一批key：先查缓存与负缓存，未命中的批量回源
+// 也用于处理来自其他节点的批量请求
+func (g *Group) getMultiLocally(ctx context.Context, keys []string) (map[string]ByteView, BatchError) {
+	if g.bypassing() {
+		return g.getMultiBypass(ctx, keys)
+	}
+	values := make(map[string]ByteView, len(keys))
+	cached := make(BatchError)
+	var misses []string
+	for _, key := range keys {
+		if v, ok := g.getMain(key); ok {
+			values[key] = v
+			continue
+		}
+		if nv, ok := g.negCache.get(key); ok {
+			if nv.Len() > 0 {
+				cached[key] = negativeError(nv) // 负缓存的加载错误
+			}
+			continue // 近期已确认不存在
+		}
+		misses = append(misses, key)
+	}
+
+	loaded, failed := g.loadMultiLocally(ctx, misses)
+	for k, v := range loaded {
+		values[k] = v
+	}
+	for k, err := range cached {
+		failed[k] = err
+	}
+	if len(failed) == 0 {
+		return values, nil
+	}
+	return values, failed
+}
+
+// loadMultiLocally 对本节点负责的key回源并回填mainCache
+// Getter实现BatchGetter时先查L2，其余key只调用一次BatchGetter，否则逐个key经load加载；
+// 批量结果中明确不存在的key写入负缓存
+func (g *Group) loadMultiLocally(ctx context.Context, keys []string) (map[string]ByteView, BatchError) {
+	values := make(map[string]ByteView, len(keys))
+	failed := make(BatchError)
+	if len(keys) == 0 {
+		return values, failed
+	}
+
+	bg := g.batchGetter
+	if bg == nil {
+		for _, key := range keys {
+			v, err := g.load(ctx, key)
+			if err != nil {
+				failed[key] = err
+				continue
+			}
+			values[key] = v
+		}
+		return values, failed
+	}
+
+	g.stats.loads.Add(int64(len(keys)))
+	g.stats.loadsDeduped.Add(int64(len(keys)))
+	loads := make(map[string]*inflightLoad, len(keys))
+	misses := make([]string, 0, len(keys))
+	for _, k := range keys {
+		loads[k] = g.inflight.begin(k)
+		if v, ok := g.loadL2(k, loads[k]); ok { // 被淘汰到L2的key不回源
+			values[k] = v
+			continue
+		}
+		misses = append(misses, k)
+	}
+	keys = misses
+	if len(keys) == 0 {
+		return values, failed
+	}
+	if err := g.waitOrigin(ctx); err != nil { // 一次批量回源只占一个令牌
+		for _, k := range keys {
+			g.inflight.end(loads[k])
+			failed[k] = fmt.Errorf("waiting for origin budget: %w", err)
+		}
+		return values, failed
+	}
+	spanCtx, end := g.startSpan(ctx, spanGetter, "") // 批量回源不对应单个key
+	found, err := bg.GetMulti(spanCtx, keys)
+	end(err)
+	if g.metrics != nil {
+		g.metrics.RecordLoad(g.name, err)
+	}
+	for k, err := range splitBatchError(keys, err) {
+		failed[k] = fmt.Errorf("getter failed: %w", err)
+	}
+	g.stats.localLoadErrs.Add(int64(len(failed)))
+	g.stats.localLoads.Add(int64(len(found)))
+
+	for _, k := range keys {
+		cacheable := g.cacheable(loads[k])
+		if b, ok := found[k]; ok {
+			value := g.loadedView(k, b)
+			if cacheable {
+				g.populateCache(k, value)
+			}
+			values[k] = value
+		} else if _, isErr := failed[k]; !isErr && cacheable {
+			g.cacheNegative(k, ErrNotFound) // 未配置WithNegativeCache时不缓存
+		}
+	}
+	return values, failed
+}
+
+// splitBatchError 将批量调用的错误拆分为逐key错误
+// BatchError 原样返回；其他错误视为整批失败，每个key都记为该错误
+func splitBatchError(keys []string, err error) BatchError {
+	if err == nil {
+		return nil
+	}
+	var be BatchError
+	if errors.As(err, &be) {
+		return be
+	}
+	failed := make(BatchError, len(keys))
+	for _, k := range keys {
+		failed[k] = err
+	}
+	return failed
+}
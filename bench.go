@@ -0,0 +1,126 @@
+package geecache
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/metrics"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBenchDuration = 300 * time.Millisecond
+	maxBenchDuration     = 5 * time.Second
+	mutexWaitMetric      = "/sync/mutex/wait/total:seconds"
+)
+
+// BenchResult is the outcome of SelfBenchmark.
+type BenchResult struct {
+	Duration   time.Duration `json:"duration"`
+	Goroutines int           `json:"goroutines"` // parallel workers, GOMAXPROCS
+	// HitOpsPerSec is the rate of cache hits on a warm scratch cache.
+	HitOpsPerSec float64 `json:"hit_ops_per_sec"`
+	// AddEvictOpsPerSec is the rate of adds into a full scratch cache, each
+	// of which evicts an entry.
+	AddEvictOpsPerSec float64 `json:"add_evict_ops_per_sec"`
+	// MutexWaitPerSec estimates lock contention under live traffic: seconds
+	// goroutines spent blocked on mutexes per wall-clock second, sampled
+	// before the benchmarks start.
+	MutexWaitPerSec float64 `json:"mutex_wait_per_sec"`
+}
+
+// SelfBenchmark measures the cache on this node for about d, split between
+// sampling live lock contention and the two micro-benchmarks. The
+// benchmarks run on scratch caches, so group contents are left alone, but
+// they do compete with live traffic for CPU. d is capped at 5s.
+func SelfBenchmark(d time.Duration) BenchResult {
+	if d <= 0 {
+		d = defaultBenchDuration
+	}
+	d = min(d, maxBenchDuration)
+	procs := runtime.GOMAXPROCS(0)
+	res := BenchResult{Duration: d, Goroutines: procs}
+	phase := d / 3
+
+	before := mutexWait()
+	time.Sleep(phase)
+	res.MutexWaitPerSec = (mutexWait() - before) / phase.Seconds()
+
+	const keys = 1024
+	warm := newShardedCache(64<<20, procs, nil)
+	for i := 0; i < keys; i++ {
+		warm.add(strconv.Itoa(i), ByteView{b: make([]byte, 64)})
+	}
+	res.HitOpsPerSec = benchParallel(procs, phase, func(i int) {
+		warm.get(strconv.Itoa(i % keys))
+	})
+
+	// room for a few entries per shard, so nearly every add evicts
+	full := newShardedCache(int64(procs)*8*128, procs, nil)
+	value := ByteView{b: make([]byte, 64)}
+	var next atomic.Int64
+	res.AddEvictOpsPerSec = benchParallel(procs, phase, func(int) {
+		full.add(strconv.FormatInt(next.Add(1), 10), value)
+	})
+	return res
+}
+
+// benchParallel runs op on n goroutines for d and returns the total rate.
+func benchParallel(n int, d time.Duration, op func(i int)) float64 {
+	var (
+		ops  atomic.Int64
+		stop atomic.Bool
+		wg   sync.WaitGroup
+	)
+	start := time.Now()
+	for w := 0; w < n; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			i := w
+			for ; !stop.Load(); i++ {
+				op(i)
+			}
+			ops.Add(int64(i - w))
+		}(w)
+	}
+	time.Sleep(d)
+	stop.Store(true)
+	wg.Wait()
+	return float64(ops.Load()) / time.Since(start).Seconds()
+}
+
+// mutexWait returns the process's cumulative mutex wait in seconds.
+func mutexWait() float64 {
+	s := []metrics.Sample{{Name: mutexWaitMetric}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	return s[0].Value.Float64()
+}
+
+// benchRunning is set while an admin request runs SelfBenchmark, which
+// loads every CPU and so must not be run by several requests at once.
+var benchRunning atomic.Bool
+
+// serveBench runs SelfBenchmark for the "duration" query parameter, one
+// request at a time; a request made while a run is in progress gets 409.
+func serveBench(w http.ResponseWriter, r *http.Request) {
+	var d time.Duration
+	if s := r.URL.Query().Get("duration"); s != "" {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			writeError(w, "bad duration: "+s, http.StatusBadRequest)
+			return
+		}
+	}
+	if !benchRunning.CompareAndSwap(false, true) {
+		writeError(w, "benchmark already running", http.StatusConflict)
+		return
+	}
+	defer benchRunning.Store(false)
+	writeJSON(w, SelfBenchmark(d))
+}
@@ -0,0 +1,181 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCacheBypassed 缓存处于直连数据源模式（见WithBypassOnErrors）时，
+// Increment、Append、CAS等只作用于缓存的操作返回该错误
+var ErrCacheBypassed = errors.New("geecache: cache is bypassed")
+
+// BypassAlertFunc 在Group进入（bypassing为true，cause为触发降级的最后一个错误）
+// 或退出（bypassing为false）直连数据源模式时调用，用于发出告警
+type BypassAlertFunc func(group string, bypassing bool, cause error)
+
+// WithBypassOnErrors 缓存自身频繁出错时自动降级为直连数据源（熔断开关）
+// 缓存自身的错误指编解码失败、L2读写失败、缓存查询panic等，与数据源无关。
+// window内这类错误达到maxErrors次时，Group在cooldown内：
+//   - 不读写任何缓存层（mainCache、hotCache、L2），也不转发给其他节点
+//   - 读取（Get、GetMulti、GetWithMaxStale）经singleflight合并后直接调用Getter（仍受回源预算约束），
+//     Wait观察不到新值
+//   - 写入（Set、回填等）不写入新值，只删除旧副本，冷却结束后不会读到过时的值
+//   - 只存在于缓存中的操作（Increment、Append、CAS）返回ErrCacheBypassed
+//
+// 冷却结束后自动恢复，再次出错则重新降级。进入与退出时记录日志并调用alert（可为nil）。
+// 参数非正时不启用
+func WithBypassOnErrors(maxErrors int, window, cooldown time.Duration, alert BypassAlertFunc) GroupOption {
+	return func(g *Group) {
+		if maxErrors > 0 && window > 0 && cooldown > 0 {
+			g.bypass = &bypassSwitch{maxErrors: maxErrors, window: window, cooldown: cooldown, alert: alert}
+		}
+	}
+}
+
+// bypassSwitch 按固定时间窗口统计缓存错误的熔断开关
+type bypassSwitch struct {
+	maxErrors int
+	window    time.Duration
+	cooldown  time.Duration
+	alert     BypassAlertFunc
+
+	mu          sync.Mutex
+	windowStart time.Time
+	errors      int
+
+	active atomic.Bool
+	until  atomic.Int64 // 降级结束时间（unix纳秒）
+}
+
+// cacheFailed 记录一次缓存自身的错误，错误过多时触发降级
+func (g *Group) cacheFailed(err error) {
+	g.stats.cacheErrors.Add(1)
+	b := g.bypass
+	if b == nil {
+		return
+	}
+	now := time.Now()
+	b.mu.Lock()
+	if now.Sub(b.windowStart) > b.window {
+		b.windowStart, b.errors = now, 0
+	}
+	b.errors++
+	trip := b.errors >= b.maxErrors && !b.active.Load()
+	if trip {
+		b.errors = 0
+		b.until.Store(now.Add(b.cooldown).UnixNano())
+		b.active.Store(true)
+	}
+	b.mu.Unlock()
+
+	if trip {
+		g.stats.bypassTrips.Add(1)
+		g.logger().Error("cache is failing, bypassing it", "group", g.name, "cooldown", b.cooldown, "err", err)
+		if b.alert != nil {
+			b.alert(g.name, true, err)
+		}
+	}
+}
+
+// bypassing 报告Group当前是否处于直连数据源模式，冷却结束时恢复
+func (g *Group) bypassing() bool {
+	b := g.bypass
+	if b == nil || !b.active.Load() {
+		return false
+	}
+	if time.Now().UnixNano() < b.until.Load() {
+		return true
+	}
+	if b.active.CompareAndSwap(true, false) {
+		g.logger().Info("cache bypass ended", "group", g.name)
+		if b.alert != nil {
+			b.alert(g.name, false, nil)
+		}
+	}
+	return false
+}
+
+// loadBypass 直连数据源模式下的读取：合并并发请求后调用Getter，不经过任何缓存
+func (g *Group) loadBypass(ctx context.Context, key string) (ByteView, error) {
+	g.stats.bypassed.Add(1)
+	viewi, err := g.loader.Do(key, func() (interface{}, error) {
+		if err := g.waitOrigin(ctx); err != nil {
+			return nil, fmt.Errorf("waiting for origin budget: %w", err)
+		}
+		b, err := g.callGetter(ctx, key)
+		if err != nil {
+			return nil, fmt.Errorf("getter failed: %w", err)
+		}
+		return ByteView{b: cloneBytes(b), src: SourceLoad}, nil
+	})
+	if err != nil {
+		return ByteView{}, err
+	}
+	return viewi.(ByteView), nil
+}
+
+// getMultiBypass 直连数据源模式下的批量读取：不经过任何缓存，也不写入负缓存
+// Getter实现BatchGetter时只调用一次，否则逐个key经loadBypass读取
+func (g *Group) getMultiBypass(ctx context.Context, keys []string) (map[string]ByteView, BatchError) {
+	values := make(map[string]ByteView, len(keys))
+	failed := make(BatchError)
+	if bg := g.batchGetter; bg == nil {
+		for _, key := range keys {
+			v, err := g.loadBypass(ctx, key)
+			if err != nil {
+				failed[key] = err
+				continue
+			}
+			values[key] = v
+		}
+	} else if err := g.waitOrigin(ctx); err != nil {
+		for _, k := range keys {
+			failed[k] = fmt.Errorf("waiting for origin budget: %w", err)
+		}
+	} else {
+		g.stats.bypassed.Add(int64(len(keys)))
+		spanCtx, end := g.startSpan(ctx, spanGetter, "")
+		found, err := bg.GetMulti(spanCtx, keys)
+		end(err)
+		if g.metrics != nil {
+			g.metrics.RecordLoad(g.name, err)
+		}
+		for k, err := range splitBatchError(keys, err) {
+			failed[k] = fmt.Errorf("getter failed: %w", err)
+		}
+		for k, b := range found {
+			values[k] = ByteView{b: cloneBytes(b), src: SourceLoad}
+		}
+	}
+	if len(failed) == 0 {
+		return values, nil
+	}
+	return values, failed
+}
+
+// bypassPopulate 直连数据源模式下代替写入：删除key在各缓存层的旧副本，返回是否处于该模式
+func (g *Group) bypassPopulate(key string) bool {
+	if !g.bypassing() {
+		return false
+	}
+	g.negCache.remove(key)
+	g.mainCache.remove(key)
+	g.hotCache.remove(key)
+	g.dropL2(key)
+	return true
+}
+
+// lookupCacheSafe 与lookupCache相同，但把缓存代码的panic当作缓存错误与未命中处理
+func (g *Group) lookupCacheSafe(key string) (v ByteView, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.cacheFailed(fmt.Errorf("cache lookup panicked: %v", r))
+			v, ok = ByteView{}, false
+		}
+	}()
+	return g.lookupCache(key)
+}
@@ -1,9 +1,29 @@
 package geecache
 
+import (
+	"bytes"
+	"io"
+	"time"
+)
+
 // ByteView 表示一个不可变的字节数据视图  示缓存值
 // 设计目标：确保缓存值的只读特性，防止外部修改导致数据不一致
 type ByteView struct {
-	b []byte // 底层字节切片，通过封装实现访问控制
+	b   []byte    // 底层字节切片，通过封装实现访问控制
+	e   time.Time // 过期时间（零值表示永不过期）
+	t   time.Time // 写入本节点缓存的时间（由cache在写入时记录）
+	src Source    // 条目来源
+}
+
+// Expire 返回缓存值的过期时间
+// 零值表示该值没有设置TTL，永不过期
+func (v ByteView) Expire() time.Time {
+	return v.e
+}
+
+// expired 判断缓存值在给定时刻是否已过期
+func (v ByteView) expired(now time.Time) bool {
+	return !v.e.IsZero() && !now.Before(v.e)
 }
 
 // Len 返回字节视图的当前长度
@@ -32,6 +52,36 @@ func (v ByteView) String() string {
 	return string(v.b)
 }
 
+// Reader 返回读取底层数据的io.ReadSeeker（不拷贝）
+// 大值可直接交给io.Copy、http.ServeContent或解码器流式处理，
+// 避免ByteSlice的防御性拷贝；bytes.Reader只读，不会修改缓存值
+func (v ByteView) Reader() io.ReadSeeker {
+	return bytes.NewReader(v.b)
+}
+
+// At 返回下标i处的字节
+func (v ByteView) At(i int) byte {
+	return v.b[i]
+}
+
+// Slice 返回[from, to)区间的视图，与原视图共享底层数据（不拷贝）
+// 下标越界时与切片表达式一样panic
+func (v ByteView) Slice(from, to int) ByteView {
+	v.b = v.b[from:to]
+	return v
+}
+
+// SliceFrom 返回从from开始到末尾的视图（不拷贝）
+func (v ByteView) SliceFrom(from int) ByteView {
+	v.b = v.b[from:]
+	return v
+}
+
+// Equal 判断两个视图的字节内容是否相同（不比较过期时间与来源）
+func (v ByteView) Equal(b2 ByteView) bool {
+	return bytes.Equal(v.b, b2.b)
+}
+
 // cloneBytes 实现安全的数据拷贝基础方法
 // 设计要点：
 //  1. 独立函数封装拷贝逻辑，统一维护
@@ -3,13 +3,28 @@ package geecache
 import (
 	"github/lhh-gh/geecache/lru"
 	"sync"
+	"time"
 )
 
 // 核心职责：提供并发安全的缓存读写能力，隐藏底层LRU实现细节
 type cache struct {
 	mu         sync.Mutex // 互斥锁，保障并发安全
 	lru        *lru.Cache // 实际存储的LRU缓存实例（延迟初始化）
-	cacheBytes int64      // 缓存容量限制（单位：字节）
+	cacheBytes int64      // 缓存容量限制（单位：字节），<=0 表示不缓存任何条目
+	overhead   int64      // 每条目额外计入的结构开销（字节）
+
+	onEvicted func(key string, value ByteView, reason EvictReason) // 可选淘汰回调（在持有锁时调用）
+}
+
+// newLRU 延迟创建LRU实例（调用方需持有锁）
+func (c *cache) newLRU() {
+	c.lru = lru.New(c.cacheBytes, nil)
+	c.lru.SetEntryOverhead(c.overhead)
+	if c.onEvicted != nil {
+		c.lru.OnEvictedReason = func(key string, value lru.Value, reason lru.EvictReason) {
+			c.onEvicted(key, value.(ByteView), reason)
+		}
+	}
 }
 
 // add 添加缓存条目（线程安全）
@@ -17,16 +32,23 @@ type cache struct {
 //  1. 延迟初始化：首次写入时创建LRU实例，避免空缓存的内存占用
 //  2. 值类型限制：强制使用ByteView保证值不可变性
 //  3. 容量检查：由底层LRU自动处理淘汰逻辑
+//
+// 容量<=0 时不写入（注意底层LRU的maxBytes为0表示不限制，这里不沿用该语义）
 func (c *cache) add(key string, value ByteView) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.cacheBytes <= 0 {
+		return
+	}
+
 	// 延迟初始化：首次操作时创建LRU实例
 	if c.lru == nil {
-		c.lru = lru.New(c.cacheBytes, nil)
+		c.newLRU()
 	}
 
 	// 类型安全：value强制为ByteView类型
+	value.t = time.Now()
 	c.lru.Add(key, value)
 }
 
@@ -34,6 +56,7 @@ func (c *cache) add(key string, value ByteView) {
 // 安全机制：
 //  1. 双检锁模式：初始化检查与获取操作的原子性
 //  2. 类型断言：确保返回值符合ByteView类型约束
+//  3. 惰性过期：已过期的条目视为未命中，由后续写入覆盖
 //
 // 返回值：
 //
@@ -48,10 +71,352 @@ func (c *cache) get(key string) (value ByteView, ok bool) {
 		return
 	}
 
+	return c.lookup(key)
+}
+
+// getStale 获取已过期但未超过过期后window时长的条目（线程安全）
+// 未过期的条目由get返回，这里不处理
+func (c *cache) getStale(key string, window time.Duration) (value ByteView, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return
+	}
+	if v, ok := c.lru.Get(key); ok {
+		now := time.Now()
+		if bv := v.(ByteView); bv.expired(now) && now.Before(bv.e.Add(window)) {
+			return bv, true
+		}
+	}
+	return
+}
+
+// bytes 返回当前已使用的字节数（线程安全）
+func (c *cache) bytes() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return 0
+	}
+	return c.lru.Bytes()
+}
+
+// items 返回当前条目数（线程安全）
+func (c *cache) items() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return 0
+	}
+	return int64(c.lru.Len())
+}
+
+// remove 删除缓存条目（线程安全）
+// 返回值表示条目是否存在
+func (c *cache) remove(key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return false
+	}
+	return c.lru.Remove(key)
+}
+
+// resize 调整容量（线程安全），超出新容量的条目被淘汰
+// 容量<=0 时淘汰全部条目
+func (c *cache) resize(cacheBytes int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.cacheBytes = cacheBytes
+	if c.lru == nil {
+		return
+	}
+	if cacheBytes <= 0 {
+		for c.lru.Len() > 0 {
+			c.lru.RemoveOldest()
+		}
+		return
+	}
+	c.lru.Resize(cacheBytes)
+}
+
+// capacity 返回当前容量（线程安全）
+func (c *cache) capacity() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cacheBytes
+}
+
+// setEntryOverhead 设置每条目的结构开销（线程安全）
+func (c *cache) setEntryOverhead(n int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.overhead = n
+	if c.lru != nil {
+		c.lru.SetEntryOverhead(n)
+	}
+}
+
+// clear 清空缓存（线程安全）
+func (c *cache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru != nil {
+		c.lru.Purge()
+	}
+}
+
+// update 在同一把锁内完成读-改-写（原子更新）
+// 设计要点：
+//  1. fn 接收当前值（ok=false表示不存在或已过期）并返回新值
+//  2. fn 返回错误时放弃写入，缓存保持原状
+//  3. 整个过程持有互斥锁，保证并发更新不会相互覆盖
+//  4. 容量为0时不调用fn，返回ErrNoCapacity，而不是丢弃结果后报告成功
+func (c *cache) update(key string, fn func(old ByteView, ok bool) (ByteView, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cacheBytes <= 0 {
+		return ErrNoCapacity
+	}
+	if c.lru == nil {
+		c.newLRU()
+	}
+
+	old, ok := c.lookup(key)
+	value, err := fn(old, ok)
+	if err != nil {
+		return err
+	}
+	value.t = time.Now()
+	c.lru.Add(key, value)
+	return nil
+}
+
+// appendEntries 将未过期的条目追加到dst（线程安全，仅在复制期间持有锁）
+// ByteView不可变，复制的只是视图而非底层数据
+func (c *cache) appendEntries(dst []snapshotEntry, now time.Time) []snapshotEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return dst
+	}
+	c.lru.Range(func(key string, v lru.Value) bool {
+		if bv := v.(ByteView); !bv.expired(now) {
+			dst = append(dst, snapshotEntry{key: key, value: bv})
+		}
+		return true
+	})
+	return dst
+}
+
+// removeExpired 删除在cutoff时刻已过期的条目，返回删除数（线程安全）
+func (c *cache) removeExpired(cutoff time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return 0
+	}
+	var dead []string
+	c.lru.Range(func(key string, v lru.Value) bool {
+		if v.(ByteView).expired(cutoff) {
+			dead = append(dead, key)
+		}
+		return true
+	})
+	for _, key := range dead {
+		c.lru.Evict(key, lru.EvictExpired)
+	}
+	return len(dead)
+}
+
+// keys 返回所有key（含已过期但尚未清除的条目）（线程安全）
+func (c *cache) keys() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.lru == nil {
+		return nil
+	}
+	return c.lru.Keys()
+}
+
+// lookup 查询未过期的条目（调用方需持有锁且保证lru已初始化）
+func (c *cache) lookup(key string) (value ByteView, ok bool) {
 	// 类型安全断言
 	if v, ok := c.lru.Get(key); ok {
-		return v.(ByteView), true
+		if bv := v.(ByteView); !bv.expired(time.Now()) {
+			return bv, true
+		}
 	}
 
 	return
 }
+
+// shardedCache 分片并发缓存
+// 设计要点：
+//  1. 按key哈希将条目分散到N个分片，每个分片拥有独立的锁和LRU
+//  2. 不同分片上的读写互不阻塞，缓解多核高QPS下的锁竞争
+//  3. 总容量平均分配给各分片，各分片独立淘汰
+//
+// 单分片时退化为普通cache，不做哈希计算
+type shardedCache struct {
+	shards []*cache
+}
+
+// newShardedCache 创建分片缓存，n<1 时按单分片处理
+// onEvicted 为可选淘汰回调，所有分片共享
+func newShardedCache(cacheBytes int64, n int, onEvicted func(key string, value ByteView, reason EvictReason)) *shardedCache {
+	if n < 1 {
+		n = 1
+	}
+	perShard := shardBytes(cacheBytes, n)
+	sc := &shardedCache{shards: make([]*cache, n)}
+	for i := range sc.shards {
+		sc.shards[i] = &cache{cacheBytes: perShard, onEvicted: onEvicted}
+	}
+	return sc
+}
+
+// shardBytes 将总容量平均分配给n个分片
+func shardBytes(cacheBytes int64, n int) int64 {
+	perShard := cacheBytes / int64(n)
+	if cacheBytes > 0 && perShard == 0 {
+		perShard = 1 // 避免容量被整除为0而变成"不缓存"
+	}
+	return perShard
+}
+
+// resize 调整总容量，各分片平均分配
+func (sc *shardedCache) resize(cacheBytes int64) {
+	perShard := shardBytes(cacheBytes, len(sc.shards))
+	for _, c := range sc.shards {
+		c.resize(perShard)
+	}
+}
+
+// capacity 返回各分片容量之和
+func (sc *shardedCache) capacity() int64 {
+	var n int64
+	for _, c := range sc.shards {
+		n += c.capacity()
+	}
+	return n
+}
+
+// keys 逐个分片收集key
+func (sc *shardedCache) keys() []string {
+	var keys []string
+	for _, c := range sc.shards {
+		keys = append(keys, c.keys()...)
+	}
+	return keys
+}
+
+// hotKeys 返回约n个最近使用的key：每个分片按最近使用顺序贡献相同份额
+func (sc *shardedCache) hotKeys(n int) []string {
+	per := (n + len(sc.shards) - 1) / len(sc.shards)
+	var keys []string
+	for _, c := range sc.shards {
+		ks := c.keys()
+		if len(ks) > per {
+			ks = ks[:per]
+		}
+		keys = append(keys, ks...)
+	}
+	if len(keys) > n {
+		keys = keys[:n]
+	}
+	return keys
+}
+
+// setEntryOverhead 为所有分片设置每条目的结构开销
+func (sc *shardedCache) setEntryOverhead(n int64) {
+	for _, c := range sc.shards {
+		c.setEntryOverhead(n)
+	}
+}
+
+// shard 按FNV-1a哈希选择key所在分片
+func (sc *shardedCache) shard(key string) *cache {
+	if len(sc.shards) == 1 {
+		return sc.shards[0]
+	}
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+	return sc.shards[h%uint32(len(sc.shards))]
+}
+
+func (sc *shardedCache) add(key string, value ByteView) {
+	sc.shard(key).add(key, value)
+}
+
+func (sc *shardedCache) get(key string) (value ByteView, ok bool) {
+	return sc.shard(key).get(key)
+}
+
+func (sc *shardedCache) getStale(key string, window time.Duration) (value ByteView, ok bool) {
+	return sc.shard(key).getStale(key, window)
+}
+
+func (sc *shardedCache) remove(key string) bool {
+	return sc.shard(key).remove(key)
+}
+
+func (sc *shardedCache) update(key string, fn func(old ByteView, ok bool) (ByteView, error)) error {
+	return sc.shard(key).update(key, fn)
+}
+
+// clear 逐个分片清空
+func (sc *shardedCache) clear() {
+	for _, c := range sc.shards {
+		c.clear()
+	}
+}
+
+// bytes 返回所有分片已使用字节数之和
+func (sc *shardedCache) bytes() int64 {
+	var n int64
+	for _, c := range sc.shards {
+		n += c.bytes()
+	}
+	return n
+}
+
+// appendEntries 逐个分片复制条目，同一时刻只锁定一个分片
+func (sc *shardedCache) appendEntries(dst []snapshotEntry, now time.Time) []snapshotEntry {
+	for _, c := range sc.shards {
+		dst = c.appendEntries(dst, now)
+	}
+	return dst
+}
+
+// removeExpired 逐个分片清理已过期条目，同一时刻只锁定一个分片
+func (sc *shardedCache) removeExpired(cutoff time.Time) int {
+	n := 0
+	for _, c := range sc.shards {
+		n += c.removeExpired(cutoff)
+	}
+	return n
+}
+
+// items 返回所有分片条目数之和
+func (sc *shardedCache) items() int64 {
+	var n int64
+	for _, c := range sc.shards {
+		n += c.items()
+	}
+	return n
+}
@@ -0,0 +1,140 @@
+// Package cachefs 提供以 geecache.Group 为后端的 io/fs.FS
+//
+// key为文件路径（fs.ValidPath格式），值为文件内容。模板引擎、http.FileServer
+// 等只依赖fs.FS的使用方无需修改即可经分布式缓存读取文件。
+//
+// 典型用法：
+//
+//	fsys := cachefs.NewGroup("assets", 64<<20, os.DirFS("/srv/assets"))
+//	http.Handle("/", http.FileServer(http.FS(fsys)))
+//
+// 数据源是对象存储等其他系统时，自行创建Group（Getter按路径读取对象）后用 New 包装。
+package cachefs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+
+	"github/lhh-gh/geecache"
+)
+
+// FS 只读文件系统，每个文件的内容通过Group读取
+// 约束：
+//   - 只有文件，没有目录结构：根目录"."是空目录，其余路径都按文件读取
+//   - 文件的ModTime为零值，Mode为只读（0444）
+//   - 远端节点返回的错误只保留文本，不存在的文件只有在本节点加载时才能以 fs.ErrNotExist 识别
+type FS struct {
+	group *geecache.Group
+}
+
+var (
+	_ fs.FS         = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
+
+// New 以已有Group创建FS，Group的Getter负责按路径加载文件内容
+func New(group *geecache.Group) *FS {
+	return &FS{group: group}
+}
+
+// NewGroup 创建名为name的Group，未命中时从origin读取文件，并返回以其为后端的FS
+func NewGroup(name string, cacheBytes int64, origin fs.FS, opts ...geecache.GroupOption) *FS {
+	getter := geecache.ContextGetterFunc(func(_ context.Context, key string) ([]byte, error) {
+		return fs.ReadFile(origin, key)
+	})
+	return New(geecache.NewGroup(name, cacheBytes, getter, opts...))
+}
+
+// Group 返回底层Group，用于注册节点、失效文件或查看统计
+func (f *FS) Group() *geecache.Group {
+	return f.group
+}
+
+// Open 实现 fs.FS
+func (f *FS) Open(name string) (fs.File, error) {
+	if name == "." {
+		return &dir{info: fileInfo{name: ".", mode: fs.ModeDir | 0555}}, nil
+	}
+	view, err := f.get("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return &file{
+		ReadSeeker: view.Reader(),
+		info:       fileInfo{name: path.Base(name), size: int64(view.Len()), mode: 0444},
+	}, nil
+}
+
+// ReadFile 实现 fs.ReadFileFS，返回内容的副本
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	view, err := f.get("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	return view.ByteSlice(), nil
+}
+
+// Stat 实现 fs.StatFS
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	file, err := f.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return file.Stat()
+}
+
+// get 校验路径并经Group读取，错误统一包装为 *fs.PathError
+func (f *FS) get(op, name string) (geecache.ByteView, error) {
+	if !fs.ValidPath(name) {
+		return geecache.ByteView{}, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	view, err := f.group.Get(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			err = fs.ErrNotExist
+		}
+		return geecache.ByteView{}, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+	return view, nil
+}
+
+// file 缓存值上的只读文件，支持Seek（http.FileServer需要），不复制底层数据
+type file struct {
+	io.ReadSeeker
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dir 空的根目录
+type dir struct {
+	info fileInfo
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+func (d *dir) ReadDir(int) ([]fs.DirEntry, error) { return nil, nil }
+
+// fileInfo 实现 fs.FileInfo
+type fileInfo struct {
+	name string
+	size int64
+	mode fs.FileMode
+}
+
+func (i fileInfo) Name() string       { return i.name }
+func (i fileInfo) Size() int64        { return i.size }
+func (i fileInfo) Mode() fs.FileMode  { return i.mode }
+func (i fileInfo) ModTime() time.Time { return time.Time{} }
+func (i fileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i fileInfo) Sys() any           { return nil }
@@ -0,0 +1,43 @@
+package cachefs
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFS(t *testing.T) {
+	origin := fstest.MapFS{
+		"index.html":    {Data: []byte("<h1>home</h1>")},
+		"css/site.css":  {Data: []byte("body{}")},
+		"data/big.json": {Data: []byte(`{"k":"v"}`)},
+	}
+	fsys := NewGroup("cachefs", 1<<20, origin)
+
+	b, err := fs.ReadFile(fsys, "css/site.css")
+	if err != nil || string(b) != "body{}" {
+		t.Fatalf("expect site.css, got %q, %v", b, err)
+	}
+	info, err := fs.Stat(fsys, "data/big.json")
+	if err != nil || info.Name() != "big.json" || info.Size() != 9 || info.IsDir() {
+		t.Fatalf("unexpected stat %v, %v", info, err)
+	}
+	if _, err := fsys.Open("missing.txt"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expect fs.ErrNotExist, got %v", err)
+	}
+	if _, err := fsys.Open("../etc/passwd"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("expect fs.ErrInvalid, got %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	http.FileServer(http.FS(fsys)).ServeHTTP(rec, httptest.NewRequest("GET", "/css/site.css", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "body{}" {
+		t.Fatalf("expect FileServer to serve site.css, got %d %q", rec.Code, rec.Body.String())
+	}
+	if s := fsys.Group().Stats(); s.LocalLoads != 2 {
+		t.Fatalf("expect each file to be loaded once, got %d loads", s.LocalLoads)
+	}
+}
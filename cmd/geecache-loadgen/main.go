@@ -0,0 +1,346 @@
+// geecache-loadgen 启动一个带监控的示例集群并对其施加可配置的负载，
+// 输出命中率、延迟分位数与回源QPS，让性能调优的结论可复现。
+//
+// 两种运行方式：
+//
+//	# 驱动模式：在本机拉起3个节点（子进程），压测10秒
+//	geecache-loadgen -nodes 3 -dist zipf -write-ratio 0.05 -duration 10s
+//
+//	# 节点模式：由驱动模式自动调用，也可手动部署后用 -targets 压测
+//	geecache-loadgen -serve http://localhost:8001 -cluster http://localhost:8001,http://localhost:8002
+//
+// 每个节点暴露：
+//   - /_geecache/        节点间协议
+//   - /api/get?key=      应用读（Group.Get）
+//   - /api/write?key=    应用写（更新模拟数据源后 Group.Remove 失效）
+//   - /api/stats         Group统计与数据源加载次数（JSON）
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github/lhh-gh/geecache"
+)
+
+const groupName = "loadgen"
+
+var (
+	// 驱动参数
+	nodes       = flag.Int("nodes", 3, "number of local nodes to start (ignored with -targets)")
+	basePort    = flag.Int("base-port", 8001, "port of the first local node")
+	targets     = flag.String("targets", "", "comma separated node URLs of an existing cluster")
+	numKeys     = flag.Int("keys", 10000, "size of the key space")
+	dist        = flag.String("dist", "zipf", "key distribution: zipf or uniform")
+	zipfS       = flag.Float64("zipf-s", 1.1, "zipf skew parameter, must be > 1")
+	writeRatio  = flag.Float64("write-ratio", 0.0, "fraction of operations that are writes")
+	concurrency = flag.Int("concurrency", 16, "number of concurrent clients")
+	duration    = flag.Duration("duration", 10*time.Second, "how long to generate load")
+	seed        = flag.Int64("seed", 1, "random seed of the key and operation choices")
+
+	// 节点参数
+	serve         = flag.String("serve", "", "run a node at this URL instead of generating load")
+	cluster       = flag.String("cluster", "", "comma separated URLs of all nodes (node mode)")
+	cacheBytes    = flag.Int64("cache-bytes", 64<<20, "cache budget of each node")
+	valueSize     = flag.Int("value-size", 1024, "size of each value in bytes")
+	originLatency = flag.Duration("origin-latency", 2*time.Millisecond, "simulated origin latency")
+)
+
+func main() {
+	flag.Parse()
+	if *serve != "" {
+		runNode(*serve, strings.Split(*cluster, ","))
+		return
+	}
+
+	addrs := splitNonEmpty(*targets)
+	if len(addrs) == 0 {
+		var stop func()
+		addrs, stop = startLocalCluster()
+		defer stop()
+	}
+	runLoad(addrs)
+}
+
+// ---------------------------------------------------------------- 节点模式
+
+// origin 模拟数据源：固定延迟，值随写入版本变化
+type origin struct {
+	loads    atomic.Int64
+	mu       sync.Mutex
+	versions map[string]int
+}
+
+func (o *origin) Get(key string) ([]byte, error) {
+	o.loads.Add(1)
+	time.Sleep(*originLatency)
+	o.mu.Lock()
+	v := o.versions[key]
+	o.mu.Unlock()
+
+	value := make([]byte, *valueSize)
+	copy(value, fmt.Sprintf("%s@%d;", key, v))
+	return value, nil
+}
+
+func (o *origin) write(key string) {
+	o.mu.Lock()
+	o.versions[key]++
+	o.mu.Unlock()
+}
+
+// nodeStats /api/stats 的响应体
+type nodeStats struct {
+	geecache.CacheStats
+	OriginLoads int64
+}
+
+func runNode(self string, peers []string) {
+	db := &origin{versions: make(map[string]int)}
+	g := geecache.NewGroup(groupName, *cacheBytes, db)
+	pool := geecache.NewHTTPPool(self)
+	pool.Set(peers...)
+	g.RegisterPeers(pool)
+
+	mux := http.NewServeMux()
+	mux.Handle("/_geecache/", pool)
+	mux.HandleFunc("/api/get", func(w http.ResponseWriter, r *http.Request) {
+		view, err := g.GetContext(r.Context(), r.URL.Query().Get("key"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Write(view.ByteSlice())
+	})
+	mux.HandleFunc("/api/write", func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		db.write(key) // 先更新数据源，再失效缓存（cache-aside）
+		if err := g.Remove(key); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/api/stats", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(nodeStats{CacheStats: g.Stats(), OriginLoads: db.loads.Load()})
+	})
+
+	u, err := url.Parse(self)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.SetOutput(io.Discard) // 命中日志会淹没压测输出
+	if err := http.ListenAndServe(u.Host, mux); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// ---------------------------------------------------------------- 驱动模式
+
+// startLocalCluster 以子进程方式启动本地节点，返回节点地址和停止函数
+func startLocalCluster() ([]string, func()) {
+	addrs := make([]string, *nodes)
+	for i := range addrs {
+		addrs[i] = fmt.Sprintf("http://localhost:%d", *basePort+i)
+	}
+
+	var cmds []*exec.Cmd
+	stop := func() {
+		for _, cmd := range cmds {
+			cmd.Process.Kill()
+			cmd.Wait()
+		}
+	}
+	for _, addr := range addrs {
+		cmd := exec.Command(os.Args[0],
+			"-serve", addr,
+			"-cluster", strings.Join(addrs, ","),
+			"-cache-bytes", strconv.FormatInt(*cacheBytes, 10),
+			"-value-size", strconv.Itoa(*valueSize),
+			"-origin-latency", originLatency.String(),
+		)
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			stop()
+			log.Fatal(err)
+		}
+		cmds = append(cmds, cmd)
+	}
+
+	for _, addr := range addrs {
+		if err := waitReady(addr, 5*time.Second); err != nil {
+			stop()
+			log.Fatal(err)
+		}
+	}
+	return addrs, stop
+}
+
+func waitReady(addr string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		if res, err := http.Get(addr + "/api/stats"); err == nil {
+			res.Body.Close()
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return fmt.Errorf("node %s not ready after %v", addr, timeout)
+}
+
+// newKeyChooser 按配置的分布选择key
+// rand.Zipf 绑定随机源且非并发安全，每个worker各建一个
+func newKeyChooser(r *rand.Rand) func() string {
+	switch *dist {
+	case "uniform":
+		return func() string {
+			return "key" + strconv.Itoa(r.Intn(*numKeys))
+		}
+	case "zipf":
+		z := rand.NewZipf(r, *zipfS, 1, uint64(*numKeys-1))
+		if z == nil {
+			log.Fatalf("invalid zipf parameters: s=%v keys=%d", *zipfS, *numKeys)
+		}
+		return func() string {
+			return "key" + strconv.FormatUint(z.Uint64(), 10)
+		}
+	default:
+		log.Fatalf("unknown distribution %q", *dist)
+		return nil
+	}
+}
+
+// worker 统计
+type result struct {
+	reads, writes, errors int64
+	latencies             []time.Duration
+}
+
+func runLoad(addrs []string) {
+	before := collectStats(addrs)
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: *concurrency}}
+
+	results := make([]result, *concurrency)
+	deadline := time.Now().Add(*duration)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(res *result, r *rand.Rand) {
+			defer wg.Done()
+			choose := newKeyChooser(r)
+			for time.Now().Before(deadline) {
+				key := choose()
+				addr := addrs[r.Intn(len(addrs))]
+				path := "/api/get?key="
+				if r.Float64() < *writeRatio {
+					path = "/api/write?key="
+					res.writes++
+				} else {
+					res.reads++
+				}
+
+				start := time.Now()
+				resp, err := client.Get(addr + path + url.QueryEscape(key))
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+					if resp.StatusCode != http.StatusOK {
+						err = fmt.Errorf("status %s", resp.Status)
+					}
+				}
+				if err != nil {
+					res.errors++
+					continue
+				}
+				res.latencies = append(res.latencies, time.Since(start))
+			}
+		}(&results[i], rand.New(rand.NewSource(*seed+int64(i))))
+	}
+	wg.Wait()
+	after := collectStats(addrs)
+
+	report(results, before, after)
+}
+
+// collectStats 汇总所有节点的统计
+func collectStats(addrs []string) nodeStats {
+	var total nodeStats
+	for _, addr := range addrs {
+		res, err := http.Get(addr + "/api/stats")
+		if err != nil {
+			log.Fatal(err)
+		}
+		var s nodeStats
+		err = json.NewDecoder(res.Body).Decode(&s)
+		res.Body.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		total.OriginLoads += s.OriginLoads
+		total.LocalLoads += s.LocalLoads
+		total.PeerLoads += s.PeerLoads
+		total.Bytes += s.Bytes
+		total.Items += s.Items
+	}
+	return total
+}
+
+func report(results []result, before, after nodeStats) {
+	var all result
+	for _, r := range results {
+		all.reads += r.reads
+		all.writes += r.writes
+		all.errors += r.errors
+		all.latencies = append(all.latencies, r.latencies...)
+	}
+	sort.Slice(all.latencies, func(i, j int) bool { return all.latencies[i] < all.latencies[j] })
+
+	seconds := duration.Seconds()
+	originLoads := after.OriginLoads - before.OriginLoads
+	hitRatio := 0.0
+	if all.reads > 0 {
+		hitRatio = 1 - float64(originLoads)/float64(all.reads)
+	}
+
+	fmt.Printf("operations   %d reads, %d writes, %d errors in %v\n", all.reads, all.writes, all.errors, *duration)
+	fmt.Printf("throughput   %.0f ops/s\n", float64(all.reads+all.writes)/seconds)
+	fmt.Printf("hit ratio    %.2f%% (origin loads per read)\n", hitRatio*100)
+	fmt.Printf("origin       %d loads, %.1f QPS\n", originLoads, float64(originLoads)/seconds)
+	fmt.Printf("cache        %d items, %d bytes across nodes\n", after.Items, after.Bytes)
+	fmt.Printf("latency      p50=%v p90=%v p99=%v p999=%v max=%v\n",
+		percentile(all.latencies, 0.50), percentile(all.latencies, 0.90),
+		percentile(all.latencies, 0.99), percentile(all.latencies, 0.999),
+		percentile(all.latencies, 1))
+}
+
+// percentile 返回已排序延迟的分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
+
+func splitNonEmpty(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,83 @@
+package geecache
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// defaultCompressMinBytes is the smallest body WithCompression gzips when
+// given a non-positive size.
+const defaultCompressMinBytes = 1024
+
+var gzipWriters = sync.Pool{New: func() any {
+	w, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+	return w
+}}
+
+// WithCompression gzips get and getmulti response bodies of at least
+// minBytes for peers that accept it. Peer clients always accept gzip, so
+// nodes with and without compression interoperate.
+func WithCompression(minBytes int) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if minBytes <= 0 {
+			minBytes = defaultCompressMinBytes
+		}
+		p.compressMin = minBytes
+	}
+}
+
+// writeBody writes a response body, gzipped when compression is enabled,
+// the body is large enough and the client accepts gzip.
+func (p *HTTPPool) writeBody(w http.ResponseWriter, r *http.Request, body []byte) {
+	w.Header().Add("Vary", "Accept-Encoding")
+	if p.compressMin == 0 || len(body) < p.compressMin || !acceptsGzip(r) {
+		w.Write(body)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	gz := gzipWriters.Get().(*gzip.Writer)
+	gz.Reset(w)
+	gz.Write(body)
+	gz.Close()
+	gzipWriters.Put(gz)
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		name, q, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.TrimSpace(name) == "gzip" && strings.ReplaceAll(q, " ", "") != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+// gzipBody decompresses a response body, closing the underlying body too.
+type gzipBody struct {
+	*gzip.Reader
+	body io.ReadCloser
+}
+
+func (b *gzipBody) Close() error {
+	b.Reader.Close()
+	return b.body.Close()
+}
+
+// decompress replaces a gzipped response body with its decompressed stream.
+func decompress(res *http.Response) error {
+	if res.Header.Get("Content-Encoding") != "gzip" {
+		return nil
+	}
+	zr, err := gzip.NewReader(res.Body)
+	if err != nil {
+		res.Body.Close()
+		return err
+	}
+	res.Body = &gzipBody{Reader: zr, body: res.Body}
+	res.Header.Del("Content-Encoding")
+	res.ContentLength = -1
+	return nil
+}
@@ -0,0 +1,135 @@
+package geecache
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// GroupConfig Group实际生效的配置（选项解析后的结果）
+// 用途：排查"这个节点实际是怎么配置的"，无需翻代码。
+// 容量取当前值（反映SetCacheBytes的调整）；接口类型的配置项以其具体类型名表示，空字符串表示未配置
+type GroupConfig struct {
+	Name string `json:"name"`
+
+	CacheBytes    int64 `json:"cache_bytes"`     // mainCache当前容量
+	HotCacheBytes int64 `json:"hot_cache_bytes"` // hotCache当前容量
+	NegCacheBytes int64 `json:"neg_cache_bytes"` // 负缓存当前容量
+	Shards        int   `json:"shards"`
+	EntryOverhead int64 `json:"entry_overhead"`
+
+	MaxAppendBytes int `json:"max_append_bytes"`
+	MaxEntryBytes  int `json:"max_entry_bytes"` // 0表示不限制
+
+	NegativeTTL     time.Duration `json:"negative_ttl"`
+	AdaptiveTTLMin  time.Duration `json:"adaptive_ttl_min"` // 0表示未启用自适应有效期
+	AdaptiveTTLMax  time.Duration `json:"adaptive_ttl_max"`
+	StaleWindow     time.Duration `json:"stale_window"`
+	JanitorInterval time.Duration `json:"janitor_interval"`
+	NegCacheFile    string        `json:"neg_cache_file,omitempty"`
+
+	BackgroundLimit int      `json:"background_limit"`
+	Prefixes        []string `json:"prefixes,omitempty"`
+	Transformers    int      `json:"transformers"`
+
+	OriginProbeInterval time.Duration `json:"origin_probe_interval"` // 0表示未启用健康探测
+	OriginWeight        float64       `json:"origin_weight"`
+	OriginRateLimit     float64       `json:"origin_rate_limit"` // 本组当前的回源速率上限（次/秒），0表示不限制
+	PeerRetries         int           `json:"peer_retries"`
+	PeerBackoff         time.Duration `json:"peer_backoff"`
+	PeerFallback        bool          `json:"peer_fallback"`
+	FallbackTTL         time.Duration `json:"fallback_ttl"`
+	PeerTimeout         time.Duration `json:"peer_timeout"`      // 0表示不限制
+	ShadowRate          float64       `json:"shadow_rate"`       // 0表示未启用旁路回源
+	RoutingKey          bool          `json:"routing_key"`       // 是否配置了WithRoutingKey
+	BypassMaxErrors     int           `json:"bypass_max_errors"` // 0表示未启用缓存熔断
+	BypassWindow        time.Duration `json:"bypass_window"`
+	BypassCooldown      time.Duration `json:"bypass_cooldown"`
+
+	Getter  string `json:"getter"`
+	Setter  string `json:"setter"` // 空字符串表示未开启写穿透
+	Batch   bool   `json:"batch"`  // Getter是否实现BatchGetter
+	Peers   string `json:"peers"`
+	L2      string `json:"l2"`
+	Metrics string `json:"metrics"`
+}
+
+// Config 返回Group当前生效的配置快照
+func (g *Group) Config() GroupConfig {
+	c := GroupConfig{
+		Name:          g.name,
+		CacheBytes:    g.mainCache.capacity(),
+		HotCacheBytes: g.hotCache.capacity(),
+		NegCacheBytes: g.negCache.capacity(),
+		Shards:        len(g.mainCache.shards),
+		EntryOverhead: g.entryOverhead,
+
+		MaxAppendBytes: g.maxAppendBytes,
+		MaxEntryBytes:  g.maxEntryBytes,
+
+		NegativeTTL:     g.negativeTTL,
+		StaleWindow:     g.staleWindow,
+		JanitorInterval: g.janitorInterval,
+		NegCacheFile:    g.negCacheFile,
+
+		BackgroundLimit: g.backgroundLimit,
+		Prefixes:        g.prefixes,
+		Transformers:    len(g.transformers),
+
+		OriginWeight: g.originWeight,
+		PeerRetries:  g.peerRetries,
+		PeerBackoff:  g.peerBackoff,
+		PeerFallback: g.peerFallback,
+		FallbackTTL:  g.fallbackTTL,
+		PeerTimeout:  g.peerTimeout,
+		ShadowRate:   g.shadowRate,
+		RoutingKey:   g.routingKeyFn != nil,
+
+		Getter:  typeName(g.getter),
+		Setter:  typeName(g.setter),
+		Batch:   g.batchGetter != nil,
+		Peers:   typeName(g.peers),
+		L2:      typeName(g.l2),
+		Metrics: typeName(g.metrics),
+	}
+	if a, ok := g.getter.(getterAdapter); ok {
+		c.Getter = typeName(a.Getter)
+	}
+	if g.adaptive != nil {
+		c.AdaptiveTTLMin, c.AdaptiveTTLMax = g.adaptive.min, g.adaptive.max
+	}
+	if g.bypass != nil {
+		c.BypassMaxErrors, c.BypassWindow, c.BypassCooldown = g.bypass.maxErrors, g.bypass.window, g.bypass.cooldown
+	}
+	if g.originProbe != nil {
+		c.OriginProbeInterval = g.probeInterval
+	}
+	if limit := g.originLimiter.Limit(); limit != rate.Inf {
+		c.OriginRateLimit = float64(limit)
+	}
+	return c
+}
+
+// typeName 返回v的具体类型名，nil返回空字符串
+func typeName(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// serveConfig 以JSON返回所有Group的配置（GET <basePath>?op=config）
+func (p *HTTPPool) serveConfig(w http.ResponseWriter) {
+	writeJSON(w, p.registry.configs())
+}
+
+// configs 返回r中所有Group的配置，键为Group名
+func (r *Registry) configs() map[string]GroupConfig {
+	configs := make(map[string]GroupConfig)
+	for name, g := range r.snapshot() {
+		configs[name] = g.Config()
+	}
+	return configs
+}
@@ -67,6 +67,30 @@ func (m *Map) Add(keys ...string) {
 	sort.Ints(m.keys) // 哈希环排序，支持二分查找
 }
 
+// Remove 将真实节点及其全部虚拟节点移出哈希环
+// 核心流程：
+//  1. 按Add相同的规则重新计算该节点的虚拟节点哈希
+//  2. 仅删除仍映射到该节点的虚拟节点（哈希冲突时可能已被其他节点占用）
+//  3. 过滤哈希环，过滤保持原有顺序，无需重新排序
+//
+// 典型场景：节点下线时调用，其负责的key自动顺延到环上的下一个节点
+func (m *Map) Remove(key string) {
+	for i := 0; i < m.replicas; i++ {
+		hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
+		if m.hashMap[hash] == key {
+			delete(m.hashMap, hash)
+		}
+	}
+
+	keys := m.keys[:0]
+	for _, hash := range m.keys {
+		if _, ok := m.hashMap[hash]; ok {
+			keys = append(keys, hash)
+		}
+	}
+	m.keys = keys
+}
+
 // Get 根据键查找对应的真实节点
 // 执行流程：
 //  1. 计算键的哈希值
@@ -93,3 +117,23 @@ func (m *Map) Get(key string) string {
 	// 环状处理：当查找结果超出范围时取模回绕
 	return m.hashMap[m.keys[idx%len(m.keys)]]
 }
+
+// GetExcept 与Get相同，但跳过skip返回true的真实节点，沿环顺时针取下一个节点
+// 典型场景：所属节点故障时查找其后继节点作为临时所属节点；
+// 所有节点都被跳过时返回空字符串
+func (m *Map) GetExcept(key string, skip func(node string) bool) string {
+	if len(m.keys) == 0 {
+		return ""
+	}
+
+	hash := int(m.hash([]byte(key)))
+	idx := sort.Search(len(m.keys), func(i int) bool {
+		return m.keys[i] >= hash
+	})
+	for i := 0; i < len(m.keys); i++ {
+		if node := m.hashMap[m.keys[(idx+i)%len(m.keys)]]; !skip(node) {
+			return node
+		}
+	}
+	return ""
+}
@@ -41,3 +41,67 @@ func TestHashing(t *testing.T) {
 	}
 
 }
+
+func TestRemove(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+
+	// 2, 4, 6, 12, 14, 16, 22, 24, 26
+	hash.Add("6", "4", "2")
+
+	// Removes 4, 14, 24
+	hash.Remove("4")
+
+	testCases := map[string]string{
+		"2":  "2",
+		"3":  "6",
+		"23": "6",
+		"27": "2",
+	}
+
+	for k, v := range testCases {
+		if hash.Get(k) != v {
+			t.Errorf("Asking for %s, should have yielded %s", k, v)
+		}
+	}
+
+	if len(hash.keys) != 6 || len(hash.hashMap) != 6 {
+		t.Errorf("expect 6 virtual nodes left, got %d", len(hash.keys))
+	}
+
+	hash.Remove("6")
+	hash.Remove("2")
+	if hash.Get("1") != "" {
+		t.Errorf("expect empty ring after removing every node")
+	}
+}
+
+func TestGetExcept(t *testing.T) {
+	hash := New(3, func(key []byte) uint32 {
+		i, _ := strconv.Atoi(string(key))
+		return uint32(i)
+	})
+
+	// 2, 4, 6, 12, 14, 16, 22, 24, 26
+	hash.Add("6", "4", "2")
+
+	skip4 := func(node string) bool { return node == "4" }
+	testCases := map[string]string{
+		"2":  "2",
+		"3":  "6",
+		"23": "6",
+		"27": "2",
+	}
+
+	for k, v := range testCases {
+		if got := hash.GetExcept(k, skip4); got != v {
+			t.Errorf("Asking for %s without 4, should have yielded %s, got %s", k, v, got)
+		}
+	}
+
+	if got := hash.GetExcept("1", func(string) bool { return true }); got != "" {
+		t.Errorf("expect empty result when every node is skipped, got %s", got)
+	}
+}
@@ -0,0 +1,51 @@
+// Package discovery keeps an HTTPPool's peer list in sync with an external
+// membership source: an etcd prefix or a DNS name such as a Kubernetes
+// headless Service.
+//
+//	reg, err := discovery.RegisterEtcd(ctx, client, "/geecache/nodes/", self, pool, 10)
+//	defer reg.Close()
+//
+//	w := &discovery.DNSWatcher{Name: "geecache.default.svc.cluster.local", Port: 8001}
+//	go w.Run(ctx, pool)
+package discovery
+
+import (
+	"log/slog"
+	"sort"
+)
+
+// PeerSetter receives the current peer list; *geecache.HTTPPool implements it.
+type PeerSetter interface {
+	Set(peers ...string)
+}
+
+// Logger receives the recoverable failures of a watcher or registration.
+// *slog.Logger and every geecache.Logger implement it.
+type Logger interface {
+	Warn(msg string, args ...any)
+}
+
+// loggerOr returns l, or slog.Default() when l is nil.
+func loggerOr(l Logger) Logger {
+	if l == nil {
+		return slog.Default()
+	}
+	return l
+}
+
+// members tracks the peers announced under their registry keys.
+type members map[string]string
+
+// list returns the announced peers, sorted and without duplicates.
+func (m members) list() []string {
+	seen := make(map[string]bool, len(m))
+	peers := make([]string, 0, len(m))
+	for _, peer := range m {
+		if peer != "" && !seen[peer] {
+			seen[peer] = true
+			peers = append(peers, peer)
+		}
+	}
+	sort.Strings(peers)
+	return peers
+}
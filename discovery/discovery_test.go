@@ -0,0 +1,66 @@
+package discovery
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMembersList(t *testing.T) {
+	m := members{
+		"/nodes/b": "http://b",
+		"/nodes/a": "http://a",
+		"/nodes/c": "http://a", // registered twice
+		"/nodes/d": "",
+	}
+	if got, expect := m.list(), []string{"http://a", "http://b"}; !reflect.DeepEqual(got, expect) {
+		t.Fatalf("expect %v, got %v", expect, got)
+	}
+}
+
+type recordingPool struct {
+	sets chan []string
+}
+
+func (p *recordingPool) Set(peers ...string) { p.sets <- peers }
+
+func TestDNSWatcher(t *testing.T) {
+	answers := make(chan []string, 3)
+	answers <- []string{"10.0.0.2", "10.0.0.1"}
+	answers <- []string{"10.0.0.1", "10.0.0.2"} // unchanged, no Set
+	answers <- []string{"10.0.0.1", "fd00::3"}
+	w := &DNSWatcher{
+		Name:     "geecache.default.svc.cluster.local",
+		Port:     8001,
+		Interval: time.Millisecond,
+		Lookup: func(ctx context.Context, host string) ([]string, error) {
+			select {
+			case a := <-answers:
+				return a, nil
+			default:
+				return nil, errors.New("no more answers")
+			}
+		},
+	}
+	pool := &recordingPool{sets: make(chan []string, 3)}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx, pool)
+
+	expect := [][]string{
+		{"http://10.0.0.1:8001", "http://10.0.0.2:8001"},
+		{"http://10.0.0.1:8001", "http://[fd00::3]:8001"},
+	}
+	for _, e := range expect {
+		select {
+		case got := <-pool.sets:
+			if !reflect.DeepEqual(got, e) {
+				t.Fatalf("expect %v, got %v", e, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expect the pool to be set to %v", e)
+		}
+	}
+}
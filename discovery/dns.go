@@ -0,0 +1,94 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// defaultDNSInterval is how often a DNSWatcher re-resolves by default.
+const defaultDNSInterval = 10 * time.Second
+
+// DNSWatcher keeps a pool in sync with the addresses of a DNS name, such as
+// a Kubernetes headless Service ("geecache.default.svc.cluster.local")
+// that resolves to the IP of every ready pod. Each node's self URL must be
+// built the same way, e.g. "http://" + podIP + ":8001", so it is found in
+// the resolved list.
+type DNSWatcher struct {
+	Name     string        // DNS name to resolve
+	Port     int           // peer port on every address
+	Scheme   string        // URL scheme of the peers; "" means "http"
+	Interval time.Duration // time between lookups; 0 means 10s
+
+	// Lookup resolves Name to addresses; nil means net.DefaultResolver.LookupHost.
+	Lookup func(ctx context.Context, host string) ([]string, error)
+
+	// Logger receives lookup failures; nil means slog.Default().
+	Logger Logger
+}
+
+// Run resolves Name now and then every Interval, calling pool.Set whenever
+// the address set changes. Lookup failures are logged and the previous
+// peer list is kept. Run blocks until ctx is done and returns ctx.Err().
+func (w *DNSWatcher) Run(ctx context.Context, pool PeerSetter) error {
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultDNSInterval
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	var last []string
+	for {
+		peers, err := w.resolve(ctx)
+		if err != nil {
+			loggerOr(w.Logger).Warn("resolving peers", "name", w.Name, "err", err)
+		} else if !equal(peers, last) {
+			pool.Set(peers...)
+			last = peers
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// resolve returns the sorted peer URLs of the current addresses.
+func (w *DNSWatcher) resolve(ctx context.Context) ([]string, error) {
+	lookup := w.Lookup
+	if lookup == nil {
+		lookup = net.DefaultResolver.LookupHost
+	}
+	addrs, err := lookup(ctx, w.Name)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses")
+	}
+	scheme := w.Scheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	m := make(members, len(addrs))
+	for _, addr := range addrs {
+		m[addr] = scheme + "://" + net.JoinHostPort(addr, strconv.Itoa(w.Port))
+	}
+	return m.list(), nil
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
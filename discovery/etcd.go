@@ -0,0 +1,165 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+// retryInterval is how long an etcd registration waits before
+// re-registering after its lease or watch is lost.
+const retryInterval = time.Second
+
+// EtcdOption configures an etcd registration.
+type EtcdOption func(*EtcdRegistration)
+
+// WithEtcdLogger sets where a registration reports a lost lease or watch;
+// the default is slog.Default().
+func WithEtcdLogger(l Logger) EtcdOption {
+	return func(r *EtcdRegistration) {
+		r.log = l
+	}
+}
+
+// EtcdRegistration is a node's membership in an etcd-backed cluster.
+type EtcdRegistration struct {
+	client *clientv3.Client
+	prefix string
+	self   string
+	ttl    int64
+	pool   PeerSetter
+	log    Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	lease  clientv3.LeaseID // guarded by the run goroutine
+}
+
+// RegisterEtcd announces self under prefix with a lease of ttlSeconds,
+// kept alive for as long as the registration is open, and calls pool.Set
+// with every peer under prefix now and whenever membership changes.
+// A node that dies without Close drops out once its lease expires.
+//
+// The initial registration and peer list are done before RegisterEtcd
+// returns; a lost lease or watch is re-established in the background.
+func RegisterEtcd(ctx context.Context, client *clientv3.Client, prefix, self string, pool PeerSetter, ttlSeconds int64, opts ...EtcdOption) (*EtcdRegistration, error) {
+	if ttlSeconds <= 0 {
+		return nil, fmt.Errorf("discovery: ttl must be positive, got %d", ttlSeconds)
+	}
+	r := &EtcdRegistration{
+		client: client,
+		prefix: prefix,
+		self:   self,
+		ttl:    ttlSeconds,
+		pool:   pool,
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	r.log = loggerOr(r.log)
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+
+	keepAlive, m, rev, err := r.register(ctx)
+	if err != nil {
+		r.cancel()
+		return nil, err
+	}
+	go r.run(keepAlive, m, rev)
+	return r, nil
+}
+
+// Close stops watching and revokes the lease so self leaves the peer
+// lists of the other nodes at once.
+func (r *EtcdRegistration) Close() error {
+	r.cancel()
+	<-r.done
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err := r.client.Revoke(ctx, r.lease)
+	return err
+}
+
+// register grants a lease, announces self and loads the current peers.
+// It returns the keepalive channel, the members and their revision.
+func (r *EtcdRegistration) register(ctx context.Context) (<-chan *clientv3.LeaseKeepAliveResponse, members, int64, error) {
+	lease, err := r.client.Grant(ctx, r.ttl)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("discovery: granting lease: %w", err)
+	}
+	if _, err := r.client.Put(ctx, r.prefix+r.self, r.self, clientv3.WithLease(lease.ID)); err != nil {
+		return nil, nil, 0, fmt.Errorf("discovery: registering %s: %w", r.self, err)
+	}
+	keepAlive, err := r.client.KeepAlive(r.ctx, lease.ID)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("discovery: keeping lease alive: %w", err)
+	}
+	res, err := r.client.Get(ctx, r.prefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("discovery: listing peers: %w", err)
+	}
+	m := make(members, len(res.Kvs))
+	for _, kv := range res.Kvs {
+		m[string(kv.Key)] = string(kv.Value)
+	}
+	r.lease = lease.ID
+	r.pool.Set(m.list()...)
+	return keepAlive, m, res.Header.Revision, nil
+}
+
+// run follows membership changes until the registration is closed,
+// re-registering whenever the lease or the watch is lost.
+func (r *EtcdRegistration) run(keepAlive <-chan *clientv3.LeaseKeepAliveResponse, m members, rev int64) {
+	defer close(r.done)
+	for {
+		r.watch(keepAlive, m, rev)
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(retryInterval):
+			}
+			var err error
+			if keepAlive, m, rev, err = r.register(r.ctx); err == nil {
+				break
+			}
+			r.log.Warn("re-registering", "self", r.self, "err", err)
+		}
+	}
+}
+
+// watch applies membership events after rev to m. It returns when the
+// registration is closed or the lease or watch is lost.
+func (r *EtcdRegistration) watch(keepAlive <-chan *clientv3.LeaseKeepAliveResponse, m members, rev int64) {
+	ctx, cancel := context.WithCancel(r.ctx)
+	defer cancel()
+	events := r.client.Watch(ctx, r.prefix, clientv3.WithPrefix(), clientv3.WithRev(rev+1))
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case _, ok := <-keepAlive:
+			if !ok {
+				r.log.Warn("lease lost", "self", r.self)
+				return
+			}
+		case wr, ok := <-events:
+			if !ok || wr.Err() != nil {
+				r.log.Warn("watch ended", "prefix", r.prefix, "err", wr.Err())
+				return
+			}
+			for _, ev := range wr.Events {
+				switch ev.Type {
+				case clientv3.EventTypePut:
+					m[string(ev.Kv.Key)] = string(ev.Kv.Value)
+				case clientv3.EventTypeDelete:
+					delete(m, string(ev.Kv.Key))
+				}
+			}
+			r.pool.Set(m.list()...)
+		}
+	}
+}
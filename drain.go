@@ -0,0 +1,147 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultDrainGrace is how long a draining node keeps serving peers after
+// announcing its departure, so requests routed on not yet updated rings
+// still succeed.
+const defaultDrainGrace = 2 * time.Second
+
+// WithDrainGrace sets the grace period used by Drain.
+func WithDrainGrace(d time.Duration) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if d >= 0 {
+			p.drainGrace = d
+		}
+	}
+}
+
+// Drain prepares this node for a planned shutdown:
+//
+//  1. every peer is told that this node is leaving and drops it from its
+//     ring, so the keys it owned move to their ring successors;
+//  2. peer requests are still served for the grace period;
+//  3. new peer requests are then refused with 503, and Drain waits for the
+//     ones in flight to finish.
+//
+// ctx bounds the whole procedure; the caller shuts the server down after
+// Drain returns. Failed announcements are reported but do not stop the
+// drain: those peers find out when their requests start failing.
+func (p *HTTPPool) Drain(ctx context.Context) error {
+	var errs []error
+	for _, peer := range p.peerNames() {
+		h := p.getter(peer)
+		if h == nil {
+			continue // left in the meantime
+		}
+		if err := h.leave(ctx, p.self); err != nil {
+			errs = append(errs, fmt.Errorf("announcing departure to %s: %w", peer, err))
+		}
+	}
+
+	grace := time.NewTimer(p.drainGrace)
+	defer grace.Stop()
+	select {
+	case <-grace.C:
+	case <-ctx.Done():
+	}
+	p.draining.Store(true)
+
+	for p.serving.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.Join(append(errs, ctx.Err())...)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// peerNames returns the names of all peers except self.
+func (p *HTTPPool) peerNames() []string {
+	getters := p.ring().getters
+	names := make([]string, 0, len(getters))
+	for peer := range getters {
+		if peer != p.self {
+			names = append(names, peer)
+		}
+	}
+	return names
+}
+
+// removePeer drops a departed peer from the ring.
+func (p *HTTPPool) removePeer(peer string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.ring().getters
+	if _, ok := old[peer]; !ok || peer == p.self {
+		return
+	}
+	getters := make(map[string]*httpGetter, len(old)-1)
+	for name, h := range old {
+		if name != peer {
+			getters[name] = h
+		}
+	}
+	p.state.Store(newPeerRing(getters))
+	old[peer].closeIdle()
+}
+
+// serveMembership handles requests addressed to the pool itself rather
+// than to a group, selected by the "op" query parameter:
+//
+//	POST ?op=leave                  the peer named in the body leaves the pool
+//	GET  ?op=hello                  startup handshake
+//	GET  ?op=config                 configuration of every group
+func (p *HTTPPool) serveMembership(w http.ResponseWriter, r *http.Request) {
+	switch op := r.URL.Query().Get("op"); {
+	case r.Method == http.MethodGet && op == "hello":
+		p.serveHello(w)
+		return
+	case r.Method == http.MethodGet && op == "config":
+		p.serveConfig(w)
+		return
+	case r.Method != http.MethodPost || op != "leave":
+		writeError(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	peer := strings.TrimSpace(string(body))
+	p.logger().Info("peer is leaving", "self", p.self, "peer", peer)
+	p.removePeer(peer)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// leave tells the peer that self is leaving the pool.
+func (h *httpGetter) leave(ctx context.Context, self string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.baseURL+"?op=leave", strings.NewReader(self))
+	if err != nil {
+		return err
+	}
+	h.sign(req)
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return serverError(res)
+	}
+	return nil
+}
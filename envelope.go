@@ -0,0 +1,99 @@
+package geecache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// envelopeMagic 标记带版本信封的值；没有该前缀的值视为版本0（启用信封前写入的旧值）
+const envelopeMagic = "\x00\xfe"
+
+// Migration 将值从某个版本迁移到下一个版本
+type Migration func(key string, old []byte) ([]byte, error)
+
+// Envelope 带版本号的值信封，是一个Transformer，通过WithTransformers启用
+// 长TTL缓存会比改变值格式的代码部署活得更久：读到旧版本的值时，
+// 依次调用注册的迁移函数升级到当前版本，并以当前版本写回缓存。
+//
+// 格式：magic "\x00\xfe" | 版本号（uvarint）| 值
+//
+// 注意：
+//  1. 缺少某一步迁移、或值的版本高于当前版本（如回滚部署）时解码失败，
+//     该条目被删除并重新回源
+//  2. 未加信封的旧值若恰好以magic开头会被误判，应在值格式不可能如此时启用
+//  3. Migrate应在创建Group前调用完毕，Envelope本身不做并发保护
+//
+// 典型用法：
+//
+//	env := geecache.NewEnvelope(2).
+//		Migrate(0, addCurrencyField).
+//		Migrate(1, renameAmount)
+//	geecache.NewGroup("prices", 64<<20, getter, geecache.WithTransformers(env))
+type Envelope struct {
+	version    uint64
+	migrations map[uint64]Migration
+}
+
+var (
+	_ Transformer = (*Envelope)(nil)
+	_ Migrator    = (*Envelope)(nil)
+)
+
+// NewEnvelope 创建当前版本为version的信封
+func NewEnvelope(version uint64) *Envelope {
+	return &Envelope{version: version, migrations: make(map[uint64]Migration)}
+}
+
+// Migrate 注册从版本from升级到from+1的迁移函数，返回e以便链式调用
+func (e *Envelope) Migrate(from uint64, fn Migration) *Envelope {
+	e.migrations[from] = fn
+	return e
+}
+
+// Encode 实现Transformer，以当前版本封装值
+func (e *Envelope) Encode(_ string, value []byte) ([]byte, error) {
+	b := make([]byte, 0, len(envelopeMagic)+binary.MaxVarintLen64+len(value))
+	b = append(b, envelopeMagic...)
+	b = binary.AppendUvarint(b, e.version)
+	return append(b, value...), nil
+}
+
+// Decode 实现Transformer，拆开信封并把旧版本的值迁移到当前版本
+func (e *Envelope) Decode(key string, stored []byte) ([]byte, error) {
+	version, value, err := parseEnvelope(stored)
+	if err != nil {
+		return nil, err
+	}
+	if version > e.version {
+		return nil, fmt.Errorf("value version %d is newer than %d", version, e.version)
+	}
+	for ; version < e.version; version++ {
+		fn, ok := e.migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("no migration from version %d", version)
+		}
+		if value, err = fn(key, value); err != nil {
+			return nil, fmt.Errorf("migrating from version %d: %w", version, err)
+		}
+	}
+	return value, nil
+}
+
+// Outdated 实现Migrator，报告存储的值是否为旧版本
+func (e *Envelope) Outdated(stored []byte) bool {
+	version, _, err := parseEnvelope(stored)
+	return err == nil && version < e.version
+}
+
+// parseEnvelope 解析信封，没有magic前缀的值视为版本0
+func parseEnvelope(stored []byte) (uint64, []byte, error) {
+	if !bytes.HasPrefix(stored, []byte(envelopeMagic)) {
+		return 0, stored, nil
+	}
+	version, n := binary.Uvarint(stored[len(envelopeMagic):])
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("malformed value envelope")
+	}
+	return version, stored[len(envelopeMagic)+n:], nil
+}
@@ -0,0 +1,55 @@
+package geecache
+
+import "github/lhh-gh/geecache/lru"
+
+// EvictReason 条目离开缓存的原因，区分TTL过期与内存压力淘汰等情况
+type EvictReason = lru.EvictReason
+
+const (
+	EvictCapacity = lru.EvictCapacity // 超出容量被LRU淘汰
+	EvictExpired  = lru.EvictExpired  // 过期后被janitor清理
+	EvictRemoved  = lru.EvictRemoved  // 被Remove/Clear等显式删除
+	EvictReplaced = lru.EvictReplaced // 值被新值覆盖，回调收到旧值
+)
+
+// WithOnEvicted 设置mainCache条目离开缓存时的回调，可用于回写或按原因统计
+// 注意：
+//  1. 回调在持有分片锁时同步调用，不得再访问本Group的缓存
+//  2. hotCache只存放其他节点负责的key的副本，其淘汰不触发该回调
+//  3. 惰性过期的条目在被覆盖或淘汰前不会以EvictExpired触发，需配合WithJanitor
+func WithOnEvicted(fn func(key string, value ByteView, reason EvictReason)) GroupOption {
+	return func(g *Group) {
+		g.onEvicted = fn
+	}
+}
+
+// evictionHandlers 组合指标记录、L2写回与用户回调，返回mainCache与hotCache各自的淘汰回调
+func (g *Group) evictionHandlers() (main, hot func(string, ByteView, EvictReason)) {
+	if g.metrics != nil {
+		hot = func(_ string, _ ByteView, reason EvictReason) {
+			g.metrics.RecordEviction(g.name, reason)
+		}
+	}
+	var handlers []func(string, ByteView, EvictReason)
+	if hot != nil {
+		handlers = append(handlers, hot)
+	}
+	if g.l2 != nil {
+		handlers = append(handlers, g.writeBack)
+	}
+	if g.onEvicted != nil {
+		handlers = append(handlers, g.onEvicted)
+	}
+	switch len(handlers) {
+	case 0:
+	case 1:
+		main = handlers[0]
+	default:
+		main = func(key string, value ByteView, reason EvictReason) {
+			for _, h := range handlers {
+				h(key, value, reason)
+			}
+		}
+	}
+	return main, hot
+}
@@ -0,0 +1,72 @@
+package geecache
+
+import (
+	"expvar"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+var (
+	expvarOnce sync.Once
+	expvarMu   sync.Mutex // 串行化检查与发布，expvar重复发布同名变量会panic
+)
+
+// PublishExpvar 以expvar变量"geecache"发布默认注册表中所有Group的统计信息
+// 结构为geecache.<group>.<counter>，如geecache.scores.hits，
+// 已有的/debug/vars采集无需引入其他指标系统即可看到缓存状况。
+// 值在读取时生成，之后注册的Group同样可见；重复调用无副作用
+func PublishExpvar() {
+	expvarOnce.Do(func() {
+		defaultRegistry.PublishExpvar("geecache")
+	})
+}
+
+// PublishExpvar 以expvar变量name发布r中所有Group的统计信息，结构同包级函数PublishExpvar
+// expvar变量在进程内不能注销或重复发布，name已被占用时返回错误
+func (r *Registry) PublishExpvar(name string) error {
+	expvarMu.Lock()
+	defer expvarMu.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("geecache: expvar %q already published", name)
+	}
+	expvar.Publish(name, expvar.Func(r.expvarGroups))
+	return nil
+}
+
+// expvarGroups 返回r中所有Group的统计信息，键为Group名
+func (r *Registry) expvarGroups() any {
+	out := make(map[string]map[string]int64)
+	for name, g := range r.snapshot() {
+		out[name] = expvarStats(g.Stats())
+	}
+	return out
+}
+
+// expvarStats 将CacheStats转为以蛇形命名为键的计数器
+// 时长字段以纳秒表示
+func expvarStats(s CacheStats) map[string]int64 {
+	v := reflect.ValueOf(s)
+	m := make(map[string]int64, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		m[snakeCase(v.Type().Field(i).Name)] = v.Field(i).Int()
+	}
+	return m
+}
+
+// snakeCase 将驼峰命名转为蛇形命名，如LoadsDeduped转为loads_deduped
+func snakeCase(s string) string {
+	var b strings.Builder
+	for i, r := range s {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
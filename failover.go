@@ -0,0 +1,116 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// WithOwnerFailover promotes the next ring successor to owner of a peer's
+// keys once requests to that peer have failed (connection errors, 502, 503
+// or 504) for longer than threshold. When the successor is this node, it
+// loads and caches those keys itself with full owner rights, including
+// singleflight deduplication and populating mainCache. The failed peer is
+// probed every threshold and its keys return to it once it answers again;
+// the entries this node cached for them as backup owner are then dropped,
+// so reads go back to the owner instead of serving values it has since
+// changed.
+//
+// Nodes decide independently from their own traffic, so for up to
+// threshold after an outage some nodes may still route to the failed peer.
+func WithOwnerFailover(threshold time.Duration) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if threshold > 0 {
+			p.failoverAfter = threshold
+		}
+	}
+}
+
+// observe records the outcome of a request to the peer for failover.
+func (h *httpGetter) observe(res *http.Response, err error) {
+	failed := err != nil
+	if res != nil {
+		switch res.StatusCode {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			failed = true
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		return // the caller gave up; says nothing about the peer
+	}
+	if failed {
+		h.failingSince.CompareAndSwap(0, time.Now().UnixNano())
+	} else {
+		h.failingSince.Store(0)
+	}
+}
+
+// down reports whether the peer has been failing for longer than threshold.
+func (h *httpGetter) down(threshold time.Duration) bool {
+	since := h.failingSince.Load()
+	return since != 0 && time.Since(time.Unix(0, since)) > threshold
+}
+
+// owner returns the node of ring that owns key, skipping peers that are
+// down when failover is enabled.
+func (p *HTTPPool) owner(ring *peerRing, key string) string {
+	owner := ring.peers.Get(key)
+	if p.failoverAfter == 0 || owner == "" || owner == p.self {
+		return owner
+	}
+	h := ring.getters[owner]
+	if h == nil || !h.down(p.failoverAfter) {
+		return owner
+	}
+	p.watch(owner, h)
+	return ring.peers.GetExcept(key, func(node string) bool {
+		if node == p.self {
+			return false
+		}
+		g := ring.getters[node]
+		return g != nil && g.down(p.failoverAfter)
+	})
+}
+
+// watch starts probing a failed peer, once, until it answers again
+// or leaves the pool.
+func (p *HTTPPool) watch(peer string, h *httpGetter) {
+	if !h.probing.CompareAndSwap(false, true) {
+		return
+	}
+	p.logger().Warn("peer is down, promoting backup owners for its keys", "self", p.self, "peer", peer)
+	go func() {
+		defer h.probing.Store(false)
+		t := time.NewTicker(p.failoverAfter)
+		defer t.Stop()
+		for range t.C {
+			if p.getter(peer) != h {
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), p.failoverAfter)
+			res := h.hello(ctx)
+			cancel()
+			if res.Err == nil {
+				h.failingSince.Store(0)
+				p.logger().Info("peer is back, returning its keys", "self", p.self, "peer", peer)
+				p.demote(peer)
+				return
+			}
+		}
+	}()
+}
+
+// demote drops the entries of each group that this node cached as backup
+// owner of peer's keys. It runs after peer is routed to again, and drops
+// loads still in flight as well, so none of them lands afterwards.
+func (p *HTTPPool) demote(peer string) {
+	ring := p.ring()
+	for _, g := range p.registry.snapshot() {
+		for _, key := range append(g.mainCache.keys(), g.inflight.keys()...) {
+			if ring.peers.Get(g.routingKey(key)) == peer {
+				g.removeLocally(key)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package geecache
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultOriginWeight 未设置权重的Group的回源权重
+const defaultOriginWeight = 1
+
+// originScheduler 进程级回源预算，按权重在所有Group之间分配
+// 设计要点：
+//  1. 每个Group持有一个令牌桶，速率 = 总速率 × 本组权重 / 全部权重之和
+//  2. 回源（含过期后台刷新、ScheduleRefresh）前取令牌，一个Group的未命中风暴
+//     只会耗尽自己的份额，不会挤占其他Group
+//  3. 总速率或任一权重变化时立即重新分配
+//
+// 注意：份额是静态划分的，空闲Group未用的份额不会借给其他Group
+type originScheduler struct {
+	mu     sync.Mutex
+	limit  rate.Limit // 合计速率，rate.Inf 表示不限制
+	burst  int        // 合计突发量
+	groups map[*Group]float64
+}
+
+var origins = &originScheduler{limit: rate.Inf, groups: make(map[*Group]float64)}
+
+// SetOriginBudget 设置进程内所有Group合计的回源速率（次/秒）与突发量
+// perSecond<=0 表示不限制（默认）；burst<1 时按1处理
+func SetOriginBudget(perSecond float64, burst int) {
+	origins.mu.Lock()
+	defer origins.mu.Unlock()
+	origins.limit = rate.Limit(perSecond)
+	if perSecond <= 0 {
+		origins.limit = rate.Inf
+	}
+	origins.burst = max(burst, 1)
+	origins.rebalanceLocked()
+}
+
+// WithOriginWeight 设置Group在回源预算中的权重（默认1），w<=0 时保持默认值
+func WithOriginWeight(w float64) GroupOption {
+	return func(g *Group) {
+		if w > 0 {
+			g.originWeight = w
+		}
+	}
+}
+
+// SetOriginWeight 运行时调整Group的回源权重，w<=0 时忽略
+func (g *Group) SetOriginWeight(w float64) {
+	if w <= 0 {
+		return
+	}
+	origins.mu.Lock()
+	defer origins.mu.Unlock()
+	if _, ok := origins.groups[g]; ok {
+		origins.groups[g] = w
+		origins.rebalanceLocked()
+	}
+}
+
+// add 登记新的Group并重新分配
+func (s *originScheduler) add(g *Group, w float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.groups[g] = w
+	s.rebalanceLocked()
+}
+
+// remove 注销Group并把它的份额重新分配给其余Group
+func (s *originScheduler) remove(g *Group) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.groups, g)
+	s.rebalanceLocked()
+}
+
+// rebalanceLocked 按权重重新计算各Group的令牌桶（调用方需持有锁）
+func (s *originScheduler) rebalanceLocked() {
+	var total float64
+	for _, w := range s.groups {
+		total += w
+	}
+	for g, w := range s.groups {
+		if s.limit == rate.Inf {
+			g.originLimiter.SetLimit(rate.Inf)
+			continue
+		}
+		share := w / total
+		g.originLimiter.SetLimit(s.limit * rate.Limit(share))
+		g.originLimiter.SetBurst(max(int(float64(s.burst)*share), 1))
+	}
+}
+
+// waitOrigin 等待本Group的回源令牌，ctx取消或截止时间不足时返回错误
+func (g *Group) waitOrigin(ctx context.Context) error {
+	return g.originLimiter.Wait(ctx)
+}
@@ -0,0 +1,44 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithPeerFallback 所属节点不可达（连接失败或502/503/504，WithPeerRetries重试后仍失败）时
+// 改由本节点回源，单个节点故障不会导致其负责的key范围读取失败
+// 回退加载的值：
+//   - 来源为SourceFallback（非权威副本，可通过GetWithInfo识别）
+//   - 不写入mainCache（本节点不是所属节点），而是写入hotCache并在ttl后过期，
+//     所属节点恢复后读取很快回到所属节点；ttl<=0 表示不缓存
+//
+// 所属节点返回的其他错误（如数据源加载失败）不触发回退
+func WithPeerFallback(ttl time.Duration) GroupOption {
+	return func(g *Group) {
+		g.peerFallback = true
+		g.fallbackTTL = ttl
+	}
+}
+
+// loadFallback 所属节点不可达时在本节点回源，回源失败时返回peerErr与回源错误
+func (g *Group) loadFallback(ctx context.Context, key string, peerErr error) (ByteView, error) {
+	if err := g.waitOrigin(ctx); err != nil {
+		return ByteView{}, peerErr
+	}
+	load := g.inflight.begin(key)
+	b, err := g.callGetter(ctx, key)
+	cacheable := g.cacheable(load)
+	if err != nil {
+		return ByteView{}, fmt.Errorf("%w; fallback getter failed: %w", peerErr, err)
+	}
+	g.stats.fallbackLoads.Add(1)
+	value := ByteView{b: cloneBytes(b), src: SourceFallback}
+	if g.fallbackTTL > 0 {
+		value.e = time.Now().Add(g.fallbackTTL)
+		if cacheable {
+			g.addHot(key, value)
+		}
+	}
+	return value, nil
+}
@@ -1,9 +1,17 @@
 package geecache
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"github/lhh-gh/geecache/singleflight"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 // Group 表示一个逻辑独立的缓存命名空间
@@ -12,11 +20,78 @@ import (
 //  2. 协调缓存未命中时的数据加载流程
 //  3. 集成底层缓存存储与数据获取逻辑
 type Group struct {
-	name      string // 缓存组唯一标识（命名空间）
-	getter    Getter // 数据源获取接口（缓存未命中时调用）
-	mainCache cache  // 并发安全缓存实例
+	name        string              // 缓存组唯一标识（命名空间）
+	getter      ContextGetter       // 数据源获取接口（缓存未命中时调用）
+	batchGetter BatchGetter         // 可选批量加载接口（getter实现BatchGetter时非nil）
+	mainCache   *shardedCache       // 并发安全缓存实例（本节点为所属节点的key）
+	hotCache    *shardedCache       // 热点缓存（远端节点所属、但在本节点被频繁访问的key）
+	negCache    *shardedCache       // 负缓存（近期确认不存在的key，值为空、仅记录过期时间）
+	peers       PeerPicker          // 节点选择器（分布式模式下用于定位key的所属节点）
+	loader      *singleflight.Group // 合并同一key的并发加载请求
+
+	cacheBytes      int64                    // mainCache容量
+	hotCacheBytes   int64                    // hotCache容量
+	hotCacheFixed   bool                     // hotCache容量是否由WithHotCacheBytes指定（不随SetCacheBytes调整）
+	shards          int                      // 每个缓存的分片数
+	maxAppendBytes  int                      // Append后值的长度上限
+	maxEntryBytes   int                      // 可缓存值的长度上限（0表示不限制）
+	entryOverhead   int64                    // 每条目额外计入容量的结构开销（0表示不计）
+	negativeTTL     time.Duration            // 加载失败的负缓存时长（0表示不缓存）
+	adaptive        *adaptiveTTL             // 可选的自适应有效期（nil表示回源值不过期）
+	staleWindow     time.Duration            // 过期后仍可返回旧值并后台刷新的时长（0表示关闭）
+	refreshing      sync.Map                 // 正在后台刷新的key
+	janitorInterval time.Duration            // 后台清理过期条目的间隔（0表示只做惰性过期）
+	negCacheFile    string                   // 负缓存的持久化文件（空表示不持久化）
+	l2              L2                       // 可选的第二级缓存
+	originProbe     OriginProbe              // 可选的数据源健康探测
+	probeInterval   time.Duration            // 健康探测间隔
+	originDown      atomic.Bool              // 最近一次探测是否判定数据源不可用
+	originWeight    float64                  // 回源预算中的权重
+	originLimiter   *rate.Limiter            // 本组的回源令牌桶（由originScheduler设置速率）
+	peerRetries     int                      // 远端获取的瞬时失败重试次数（0表示不重试）
+	peerBackoff     time.Duration            // 首次重试前的基础等待时长
+	peerFallback    bool                     // 所属节点不可达时是否由本节点回源
+	fallbackTTL     time.Duration            // 回退加载值在hotCache中的有效期
+	peerTimeout     time.Duration            // 单次远端获取的超时（0表示不限制）
+	shadowRate      float64                  // 命中后旁路回源的抽样比例（0表示关闭）
+	inflight        inflightLoads            // 进行中的加载（删除时作废其回填）
+	routingKeyFn    RoutingKeyFunc           // 可选的路由key推导（nil表示按key本身路由）
+	tracer          Tracer                   // 可选的追踪
+	log             Logger                   // 日志输出（nil表示slog.Default()）
+	bypass          *bypassSwitch            // 可选的缓存故障熔断开关
+	setter          Setter                   // 可选的数据源写入接口（见WithSetter）
+	writeLocks      writeLocks               // 串行化同一key的写穿透
+	l2Locks         writeLocks               // 串行化同一key的L2写回与删除
+	l2Pending       sync.Map                 // 尚未执行的L2写回：key -> 待写回的值（*ByteView，兼作登记令牌）
+	keyPolicy       KeyPolicy                // 可选的按key加载策略
+	loadClasses     map[string]chan struct{} // 各并发类别的回源名额
+
+	backgroundLimit int         // 一次性后台任务的并发上限
+	bg              *background // Group拥有的后台任务
+
+	prefixes       []string                   // 分区统计的前缀（按长度降序）
+	prefixCounters map[string]*prefixCounters // 各前缀分区的请求计数（nil表示未启用）
+
+	transformers []Transformer // 值转换管道（写入时编码，读出时解码）
+	waiters      keyWaiters    // Wait中等待key写入的goroutine
+
+	metrics   MetricsRecorder                                      // 可选指标记录器
+	onEvicted func(key string, value ByteView, reason EvictReason) // 可选mainCache淘汰回调
+	rand      randSource                                           // 随机数来源（可注入以获得确定性）
+	stats     groupStats                                           // 运行时计数器
 }
 
+// ErrAppendTooLarge Append后的值超过长度上限时返回
+var ErrAppendTooLarge = errors.New("geecache: appended value exceeds size limit")
+
+// ErrNoCapacity 容量为0的Group不缓存任何值，只作用于缓存的操作（Increment、Append、CAS）返回该错误
+var ErrNoCapacity = errors.New("geecache: group has no cache capacity")
+
+// ErrNotFound Getter在数据源中不存在key时应返回（或包装）该错误
+// 与其他加载失败区分：HTTP节点以404响应，远端节点的调用方可用errors.Is识别，
+// 负缓存命中时同样保留该语义
+var ErrNotFound = errors.New("geecache: not found")
+
 // Getter 定义数据加载器接口规范
 // 设计目标：解耦缓存系统与具体数据源，提供扩展能力
 type Getter interface {
@@ -32,43 +107,66 @@ func (f GetterFunc) Get(key string) ([]byte, error) {
 	return f(key) // 直接委托给底层函数
 }
 
-var (
-	mu     sync.RWMutex              // 全局读写锁，保护groups映射
-	groups = make(map[string]*Group) // 全局缓存组注册表
-)
+// ContextGetter 支持上下文的数据加载器接口
+// 设计目标：让调用方的截止时间与取消信号传递到慢数据源
+//
+// 兼容性：NewGroup 仍接收 Getter，若传入的实现同时满足 ContextGetter，
+// 加载时优先调用 GetContext
+type ContextGetter interface {
+	GetContext(ctx context.Context, key string) ([]byte, error)
+}
+
+// ContextGetterFunc 函数类型适配器，同时实现 Getter 与 ContextGetter
+type ContextGetterFunc func(ctx context.Context, key string) ([]byte, error)
+
+// GetContext 实现ContextGetter接口方法
+func (f ContextGetterFunc) GetContext(ctx context.Context, key string) ([]byte, error) {
+	return f(ctx, key)
+}
+
+// Get 实现Getter接口方法，使用 context.Background()
+func (f ContextGetterFunc) Get(key string) ([]byte, error) {
+	return f(context.Background(), key)
+}
+
+// getterAdapter 将普通Getter适配为ContextGetter（忽略上下文）
+type getterAdapter struct {
+	Getter
+}
 
-// NewGroup 创建并注册新的缓存组（工厂方法）
+func (a getterAdapter) GetContext(_ context.Context, key string) ([]byte, error) {
+	return a.Get(key)
+}
+
+// NewGroup 在默认注册表中创建并注册新的缓存组（工厂方法）
 // 安全机制：
 //  1. 互斥锁保证并发安全
 //  2. getter非空校验（防止空指针异常）
+//  3. cacheBytes为负数视为配置错误
+//
+// cacheBytes为0表示不在本地缓存任何值：每次Get都回源（并发请求仍经singleflight合并），
+// 而不是容量不限；只作用于缓存的Increment、Append、CAS返回ErrNoCapacity
 //
 // 典型用法：
 //
 //	NewGroup("users", 1<<30, GetterFunc(func(key string) {...}))
-func NewGroup(name string, cacheBytes int64, getter Getter) *Group {
-	if getter == nil {
-		panic("nil Getter") // 严格校验防止错误配置
-	}
+func NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
+	return defaultRegistry.NewGroup(name, cacheBytes, getter, opts...)
+}
 
-	mu.Lock()
-	defer mu.Unlock()
+// GetGroup 在默认注册表中按名称查找缓存组（安全并发读）
+func GetGroup(name string) *Group {
+	return defaultRegistry.GetGroup(name)
+}
 
-	g := &Group{
-		name:      name,
-		getter:    getter,
-		mainCache: cache{cacheBytes: cacheBytes}, // 初始化容量但延迟创建LRU
-	}
-	groups[name] = g // 注册到全局表
-	return g
+// ListGroups 返回默认注册表中所有缓存组的名称（按字典序）
+func ListGroups() []string {
+	return defaultRegistry.ListGroups()
 }
 
-// GetGroup 按名称查找已注册的缓存组（安全并发读）
-// 性能优化：使用读锁替代写锁，允许并发查询
-func GetGroup(name string) *Group {
-	mu.RLock()
-	g := groups[name]
-	mu.RUnlock()
-	return g
+// DestroyGroup 注销并清空默认注册表中名为name的缓存组，见Registry.DestroyGroup
+func DestroyGroup(name string) error {
+	return defaultRegistry.DestroyGroup(name)
 }
 
 // Get 从缓存组获取键值（核心入口方法）
@@ -79,46 +177,531 @@ func GetGroup(name string) *Group {
 //   - 对调用方隐藏加载细节
 //   - 通过ByteView保证返回值的不可变性
 func (g *Group) Get(key string) (ByteView, error) {
+	return g.GetContext(context.Background(), key)
+}
+
+// GetContext 与Get相同，但允许通过ctx设置截止时间或取消加载
+// ctx 会传递给远端节点请求与ContextGetter
+func (g *Group) GetContext(ctx context.Context, key string) (ByteView, error) {
+	v, _, err := g.get(ctx, key)
+	return v, err
+}
+
+// get Get系列方法的公共实现，额外返回是否命中缓存
+func (g *Group) get(ctx context.Context, key string) (_ ByteView, _ bool, err error) {
 	if key == "" {
-		return ByteView{}, fmt.Errorf("key is required") // 防御性编程
+		return ByteView{}, false, fmt.Errorf("key is required") // 防御性编程
+	}
+	ctx, end := g.startSpan(ctx, spanGet, key)
+	defer func() { end(err) }()
+
+	// 缓存自身故障时绕过缓存直连数据源
+	if g.bypassing() {
+		g.recordGet(key, false)
+		v, err := g.loadBypass(ctx, key)
+		return v, false, err
 	}
 
 	// 缓存命中路径
-	if v, ok := g.mainCache.get(key); ok {
-		log.Println("[GeeCache] hit")
-		return v, nil
+	v, ok := g.lookupCacheSafe(key)
+	g.recordGet(key, ok)
+	if ok {
+		g.logger().Debug("cache hit", "group", g.name, "key", key)
+		g.maybeShadow(key, v)
+		return v, true, nil
+	}
+
+	// 过期不久的值：立即返回旧值并在后台刷新
+	if v, ok := g.serveStale(key); ok {
+		return v, true, nil
 	}
 
 	// 缓存未命中处理路径
-	return g.load(key)
+	v, err = g.load(ctx, key)
+	return v, false, err
+}
+
+// recordGet 记录一次Get及其命中情况（计数器与可选指标）
+func (g *Group) recordGet(key string, hit bool) {
+	g.recordPrefixGet(key, hit)
+	g.stats.gets.Add(1)
+	if hit {
+		g.stats.hits.Add(1)
+	}
+	if g.metrics != nil {
+		g.metrics.RecordGet(g.name, hit)
+	}
+}
+
+// lookupCache 依次查询mainCache与hotCache（返回解码后的值），直连数据源模式下总是未命中
+func (g *Group) lookupCache(key string) (ByteView, bool) {
+	if g.bypassing() {
+		return ByteView{}, false
+	}
+	if v, ok := g.getMain(key); ok {
+		return v, true
+	}
+	v, ok := g.hotCache.get(key)
+	return g.decodeHit(key, v, ok, g.hotCache)
+}
+
+// Set 主动写入缓存条目（写穿透场景）
+// 与Get的读穿透互补：应用在更新数据源后可直接推送新值，
+// 避免下一次读取时才因未命中而回源
+//
+// 配置了WithSetter时先写入数据源，数据源写入失败则返回其错误且不更新缓存
+//
+// 注意：写入的是防御性拷贝，调用方后续修改value不影响缓存
+func (g *Group) Set(key string, value []byte) error {
+	return g.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL 写入带有效期的缓存条目
+// 参数说明：
+//
+//	ttl - 有效期，<=0 表示永不过期
+//
+// 过期采用惰性检查：条目过期后的首次Get视为未命中并重新加载
+// 等价于 SetWithTTLContext(context.Background(), key, value, ttl)
+func (g *Group) SetWithTTL(key string, value []byte, ttl time.Duration) error {
+	return g.SetWithTTLContext(context.Background(), key, value, ttl)
+}
+
+// SetWithTTLContext 与SetWithTTL相同，ctx约束WithSetter的数据源写入与随后的副本失效
+func (g *Group) SetWithTTLContext(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+
+	view := ByteView{b: cloneBytes(value), src: SourceSet}
+	if ttl > 0 {
+		view.e = time.Now().Add(ttl)
+	}
+	if g.setter != nil {
+		return g.writeThrough(ctx, key, value, view)
+	}
+	g.populateCache(key, view)
+	return nil
+}
+
+// Append 向已有条目末尾追加数据，返回追加后的长度
+// 适用场景：在缓存中累积小批量事件，达到阈值后再统一写回数据源
+//
+// 约束：
+//   - 追加后长度超过上限（WithMaxAppendBytes）时返回ErrAppendTooLarge，原值不变
+//   - 不存在（或已过期）的条目视为空值，不会回源加载
+//   - 分布式模式下由所属节点执行，保证并发追加不丢数据
+func (g *Group) Append(key string, data []byte) (int, error) {
+	if key == "" {
+		return 0, fmt.Errorf("key is required")
+	}
+
+	if g.peers != nil {
+		if peer, ok := g.pickPeer(key); ok {
+			defer g.hotCache.remove(key) // 本地热点副本已过时
+			return peer.Append(context.Background(), g.name, key, data)
+		}
+	}
+	return g.appendLocally(key, data)
+}
+
+// appendLocally 在本节点缓存上执行追加
+func (g *Group) appendLocally(key string, data []byte) (int, error) {
+	var n int
+	err := g.updateMain(key, func(old ByteView, ok bool) (ByteView, error) {
+		n = old.Len() + len(data)
+		if n > g.maxAppendBytes {
+			return ByteView{}, ErrAppendTooLarge
+		}
+		// 新建切片而不是原地append，保证已返回给调用方的ByteView不被修改
+		b := make([]byte, 0, n)
+		b = append(b, old.b...)
+		b = append(b, data...)
+		return ByteView{b: b, e: old.e, src: SourceSet}, nil
+	})
+	return n, err
+}
+
+// CAS 比较并交换：当前值与old相同时写入new，返回是否交换成功
+// 适用场景：多节点写入方基于缓存状态实现乐观并发控制
+//
+// 比较语义：
+//   - 按字节内容比较，old通常来自先前Get返回的ByteView
+//   - 空的old可匹配不存在（或已过期）的条目，用于"不存在才创建"
+//   - 分布式模式下由所属节点执行比较与写入
+func (g *Group) CAS(key string, old ByteView, new []byte) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("key is required")
+	}
+
+	if g.peers != nil {
+		if peer, ok := g.pickPeer(key); ok {
+			defer g.hotCache.remove(key) // 本地热点副本已过时
+			return peer.CAS(context.Background(), g.name, key, old.b, new)
+		}
+	}
+	return g.casLocally(key, old.b, new)
+}
+
+// casLocally 在本节点缓存上执行比较并交换
+// 交换时沿用旧条目的过期时间
+func (g *Group) casLocally(key string, old, new []byte) (bool, error) {
+	errMismatch := errors.New("mismatch")
+	err := g.updateMain(key, func(cur ByteView, ok bool) (ByteView, error) {
+		if !bytes.Equal(cur.b, old) {
+			return ByteView{}, errMismatch
+		}
+		return ByteView{b: cloneBytes(new), e: cur.e, src: SourceSet}, nil
+	})
+	if err == errMismatch {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Remove 删除缓存条目并在集群内失效
+// 分布式失效流程：
+//  1. 通知key的所属节点删除（所属节点持有权威副本）
+//  2. 并发通知其余节点删除本地副本，避免过期数据残留
+//  3. 删除本节点副本
+//
+// 任一节点删除失败都会返回错误，调用方可重试（删除是幂等的）
+func (g *Group) Remove(key string) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	return g.removeEverywhere(key, func(ctx context.Context, peer PeerGetter) error {
+		return peer.Remove(ctx, g.name, key)
+	})
 }
 
-// load 统一控制缓存加载流程（预留分布式扩展点）
-// 当前实现：直接本地加载，后续可扩展为多节点协同
-func (g *Group) load(key string) (value ByteView, err error) {
-	return g.getLocally(key) // 当前仅本地加载，后续可添加分布式逻辑
+// removeEverywhere 按Remove的流程删除key，remove负责通知单个远端节点
+func (g *Group) removeEverywhere(key string, remove func(ctx context.Context, peer PeerGetter) error) error {
+	if g.peers != nil {
+		owner, isRemote := g.pickPeer(key)
+		if isRemote {
+			if err := remove(context.Background(), owner); err != nil {
+				return err
+			}
+		}
+
+		var (
+			wg       sync.WaitGroup
+			errMu    sync.Mutex
+			firstErr error
+		)
+		for _, peer := range g.peers.AllPeers() {
+			if isRemote && peer == owner {
+				continue // 所属节点已处理
+			}
+			wg.Add(1)
+			go func(peer PeerGetter) {
+				defer wg.Done()
+				if err := remove(context.Background(), peer); err != nil {
+					errMu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					errMu.Unlock()
+				}
+			}(peer)
+		}
+		wg.Wait()
+		if firstErr != nil {
+			return firstErr
+		}
+	}
+
+	g.removeLocally(key)
+	return nil
+}
+
+// removeLocally 仅删除本节点的缓存副本（处理来自其他节点的失效请求）
+// 同时清理mainCache与hotCache，并作废该key进行中的加载（结果不再回填）
+func (g *Group) removeLocally(key string) {
+	g.inflight.invalidate(key)
+	g.mainCache.remove(key)
+	g.hotCache.remove(key)
+	g.negCache.remove(key)
+	g.dropL2(key)
+}
+
+// SetCacheBytes 运行时调整Group的内存预算，超出新容量的条目立即淘汰
+// 典型场景：收到内存压力告警时收缩缓存，或扩容后增大预算。
+// hotCache与负缓存按默认比例随之调整（WithHotCacheBytes指定的hotCache容量保持不变）
+// n为0时清空并停止本地缓存（语义同NewGroup）；n为负数时忽略并记录日志
+func (g *Group) SetCacheBytes(n int64) {
+	if n < 0 {
+		g.logger().Warn("ignoring negative cacheBytes", "group", g.name, "bytes", n)
+		return
+	}
+	g.mainCache.resize(n)
+	if !g.hotCacheFixed {
+		g.hotCache.resize(n / defaultHotCacheRatio)
+	}
+	g.negCache.resize(n / defaultNegCacheRatio)
+}
+
+// Clear 清空本节点上该Group的全部缓存（mainCache、hotCache、负缓存与L2）
+// 用于测试与运维重置命名空间，无需重启进程；不会通知其他节点
+func (g *Group) Clear() {
+	g.mainCache.clear()
+	g.hotCache.clear()
+	g.negCache.clear()
+	if g.l2 != nil {
+		g.l2Pending.Clear()
+		if err := g.l2.Clear(); err != nil {
+			g.logger().Warn("clearing L2", "group", g.name, "err", err)
+			g.cacheFailed(err)
+		}
+	}
+}
+
+// RegisterPeers 注册节点选择器，开启分布式模式
+// 约束：每个Group只能注册一次，重复注册视为配置错误
+func (g *Group) RegisterPeers(peers PeerPicker) {
+	if g.peers != nil {
+		panic("RegisterPeerPicker called more than once")
+	}
+	g.peers = peers
+}
+
+// load 统一控制缓存加载流程
+// 执行流程：
+//  1. singleflight 合并同一key的并发加载
+//  2. key 属于远端节点时向该节点获取（不可达时按WithPeerFallback在本节点回源）
+//  3. 否则本地调用Getter回源
+//
+// 注意：并发请求共享首个请求的加载过程，因此也共享其ctx。
+// 配置了KeyPolicy时按key的策略设置超时与重试次数
+func (g *Group) load(ctx context.Context, key string) (value ByteView, err error) {
+	g.stats.loads.Add(1)
+	ctx, end := g.startSpan(ctx, spanLoad, key)
+	defer func() { end(err) }()
+	viewi, err := g.loader.Do(key, func() (interface{}, error) {
+		g.stats.loadsDeduped.Add(1)
+		policy := g.loadPolicy(key)
+		if policy.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, policy.Timeout)
+			defer cancel()
+		}
+		if g.peers != nil {
+			if peer, ok := g.pickPeer(key); ok {
+				value, err := g.getFromPeer(ctx, peer, key)
+				if err != nil {
+					g.stats.peerErrors.Add(1)
+					if g.peerFallback && retryable(err) {
+						return g.loadFallback(ctx, key, err)
+					}
+					return nil, err
+				}
+				g.stats.peerLoads.Add(1)
+				return value, nil
+			}
+		}
+		value, err := g.getLocally(ctx, key)
+		if err != nil {
+			g.stats.localLoadErrs.Add(1)
+			return nil, err
+		}
+		g.stats.localLoads.Add(1)
+		return value, nil
+	})
+	if err != nil {
+		return ByteView{}, err
+	}
+	return viewi.(ByteView), nil
+}
+
+// getFromPeer 从所属节点获取数据
+// 远端数据不写入本地mainCache（由所属节点负责缓存），
+// 而是按概率写入hotCache：被频繁访问的远端key迟早会进入热点缓存，
+// 偶发访问的key则不会挤占热点缓存空间。每次尝试受WithPeerTimeout约束，
+// 瞬时失败按WithPeerRetries（或key的策略）重试
+func (g *Group) getFromPeer(ctx context.Context, peer PeerGetter, key string) (ByteView, error) {
+	load := g.inflight.begin(key)
+	retries, backoff := g.peerRetriesFor(g.loadPolicy(key))
+	bytes, err := g.withPeerRetries(ctx, retries, backoff, func() ([]byte, error) {
+		start := time.Now()
+		ctx, end := g.startSpan(ctx, spanPeer, key)
+		bytes, err := g.withPeerTimeout(ctx, func(ctx context.Context) ([]byte, error) {
+			return peer.Get(ctx, g.name, key)
+		})
+		end(err)
+		if g.metrics != nil {
+			g.metrics.RecordPeerFetch(g.name, time.Since(start), err)
+		}
+		return bytes, err
+	})
+	cacheable := g.cacheable(load)
+	if err != nil {
+		return ByteView{}, err
+	}
+	value := ByteView{b: bytes, src: SourcePeer}
+	if cacheable && g.rand.Intn(hotCachePopulateOdds) == 0 {
+		g.addHot(key, value)
+	}
+	return value, nil
+}
+
+// Increment 对计数器类型的缓存值做原子加法，返回加后的值
+// 所属节点仲裁：
+//   - 分布式模式下请求转发给key的所属节点执行，避免多节点各自读-改-写产生竞争
+//   - 本地执行时在缓存锁内完成读-改-写
+//
+// 计数器语义：
+//   - 值以十进制字符串存储，Get可直接读取
+//   - 不存在（或已过期）的计数器从0开始，不会回源加载
+func (g *Group) Increment(key string, delta int64) (int64, error) {
+	if key == "" {
+		return 0, fmt.Errorf("key is required")
+	}
+
+	if g.peers != nil {
+		if peer, ok := g.pickPeer(key); ok {
+			defer g.hotCache.remove(key) // 本地热点副本已过时
+			return peer.Increment(context.Background(), g.name, key, delta)
+		}
+	}
+	return g.incrementLocally(key, delta)
+}
+
+// incrementLocally 在本节点缓存上执行计数器加法
+func (g *Group) incrementLocally(key string, delta int64) (int64, error) {
+	var n int64
+	err := g.updateMain(key, func(old ByteView, ok bool) (ByteView, error) {
+		if ok {
+			cur, err := strconv.ParseInt(old.String(), 10, 64)
+			if err != nil {
+				return ByteView{}, fmt.Errorf("value of %s is not an integer", key)
+			}
+			n = cur
+		}
+		n += delta
+		return ByteView{b: []byte(strconv.FormatInt(n, 10)), e: old.e, src: SourceSet}, nil
+	})
+	return n, err
 }
 
 // getLocally 本地数据加载实现
 // 关键步骤：
-//  1. 通过Getter获取原始数据
-//  2. 数据格式转换与防御性拷贝
-//  3. 回填缓存供后续请求使用
-func (g *Group) getLocally(key string) (ByteView, error) {
-	bytes, err := g.getter.Get(key)
+//  1. 负缓存命中时直接返回缓存的错误，不再回源
+//  2. 配置了L2时先查L2，命中则回填mainCache
+//  3. 通过Getter获取原始数据（失败时按配置写入负缓存）
+//  4. 数据格式转换与防御性拷贝
+//  5. 回填缓存供后续请求使用（加载期间key被删除时不回填，见inflightLoads）
+func (g *Group) getLocally(ctx context.Context, key string) (ByteView, error) {
+	if nv, ok := g.negCache.get(key); ok {
+		return ByteView{}, negativeError(nv)
+	}
+	load := g.inflight.begin(key)
+	if value, ok := g.loadL2(key, load); ok {
+		return value, nil
+	}
+
+	release, err := g.acquireClass(ctx, key)
 	if err != nil {
+		g.inflight.end(load)
+		return ByteView{}, err
+	}
+	defer release()
+	if err := g.waitOrigin(ctx); err != nil {
+		g.inflight.end(load)
+		return ByteView{}, fmt.Errorf("waiting for origin budget: %w", err)
+	}
+	bytes, err := g.callGetter(ctx, key)
+	cacheable := g.cacheable(load) // 加载期间被删除的结果不回填
+	if err != nil {
+		if cacheable {
+			g.cacheNegative(key, err)
+		}
 		return ByteView{}, fmt.Errorf("getter failed: %w", err) // 错误包装
 	}
 
 	// 封装不可变视图并缓存
-	value := ByteView{b: cloneBytes(bytes)} // 强制深拷贝
-	g.populateCache(key, value)
+	value := g.loadedView(key, bytes) // 强制深拷贝
+	if cacheable {
+		g.populateCache(key, value)
+	}
 	return value, nil
 }
 
+// callGetter 调用Getter回源并记录指标与追踪
+func (g *Group) callGetter(ctx context.Context, key string) ([]byte, error) {
+	ctx, end := g.startSpan(ctx, spanGetter, key)
+	b, err := g.getter.GetContext(ctx, key)
+	end(err)
+	if g.metrics != nil {
+		g.metrics.RecordLoad(g.name, err)
+	}
+	return b, err
+}
+
+// cacheNegative 按配置将加载失败写入负缓存
+// 上下文取消/超时属于调用方自身原因，不做缓存
+func (g *Group) cacheNegative(key string, err error) {
+	if g.negativeTTL <= 0 || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return
+	}
+	var msg []byte // 空消息表示不存在，见negativeError
+	if !errors.Is(err, ErrNotFound) {
+		msg = []byte(err.Error())
+	}
+	g.negCache.add(key, ByteView{b: msg, e: time.Now().Add(g.negativeTTL)})
+}
+
+// negativeError 由负缓存条目构造错误，空消息表示确认的不存在（包装ErrNotFound）
+// 注意：其他错误缓存的只是错误文本，原始错误类型不会保留
+func negativeError(nv ByteView) error {
+	msg := nv.String()
+	if msg == "" {
+		return fmt.Errorf("getter failed: %w (negative cached)", ErrNotFound)
+	}
+	return fmt.Errorf("getter failed: %s (negative cached)", msg)
+}
+
 // populateCache 回填缓存的标准流程
 // 分离设计：
 //   - 独立方法便于后续添加缓存策略（如写穿透/异步更新）
+//   - 配置了WithTransformers时写入编码后的值
 func (g *Group) populateCache(key string, value ByteView) {
+	if g.bypassPopulate(key) {
+		return
+	}
+	enc, err := g.encode(key, value)
+	if err != nil {
+		g.logger().Warn("encoding value", "group", g.name, "key", key, "err", err)
+		g.cacheFailed(err)
+		g.negCache.remove(key)
+		g.mainCache.remove(key) // 不缓存新值，也不能留下旧值
+		g.dropL2(key)
+		return
+	}
+	g.populateEncoded(key, enc)
+}
+
+// populateEncoded 将已编码的值写入mainCache（快照与L2中的值已是编码后的形式）
+func (g *Group) populateEncoded(key string, value ByteView) {
+	if g.bypassPopulate(key) {
+		return
+	}
+	g.negCache.remove(key) // 新值覆盖"不存在"的记录
+	if g.oversized(value) {
+		g.mainCache.remove(key) // 不缓存新值，也不能留下旧值
+		g.dropL2(key)
+		return
+	}
 	g.mainCache.add(key, value) // 线程安全写入
+	g.dropL2(key)
+	g.waiters.notify(key)
+}
+
+// oversized 判断值是否超过可缓存的长度上限
+func (g *Group) oversized(value ByteView) bool {
+	if g.maxEntryBytes > 0 && value.Len() > g.maxEntryBytes {
+		g.stats.oversized.Add(1)
+		return true
+	}
+	return false
 }
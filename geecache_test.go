@@ -1,10 +1,26 @@
 package geecache
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
+	pb "github/lhh-gh/geecache/geecachepb"
+	"io"
 	"log"
+	"log/slog"
+	"math/rand"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 var db = map[string]string{
@@ -65,3 +81,1419 @@ func TestGetGroup(t *testing.T) {
 		t.Fatalf("expect nil, but %s got", group.name)
 	}
 }
+
+func TestSet(t *testing.T) {
+	loads := 0
+	g := NewGroup("set", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			return []byte("origin"), nil
+		}))
+
+	if err := g.Set("k", []byte("pushed")); err != nil {
+		t.Fatal(err)
+	}
+	if view, err := g.Get("k"); err != nil || view.String() != "pushed" || loads != 0 {
+		t.Fatalf("expect pushed value without load, got %q (loads=%d)", view, loads)
+	}
+
+	if err := g.SetWithTTL("ttl", []byte("short"), time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if view, err := g.Get("ttl"); err != nil || view.String() != "origin" || loads != 1 {
+		t.Fatalf("expect expired entry to reload, got %q (loads=%d)", view, loads)
+	}
+
+	if err := g.Set("", []byte("v")); err == nil {
+		t.Fatal("expect error for empty key")
+	}
+}
+
+func TestAppend(t *testing.T) {
+	g := NewGroup("append", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return nil, fmt.Errorf("%s not exist", key) }),
+		WithMaxAppendBytes(6))
+
+	if n, err := g.Append("log", []byte("abc")); err != nil || n != 3 {
+		t.Fatalf("expect length 3, got %d (%v)", n, err)
+	}
+	view, _ := g.Get("log")
+	if n, err := g.Append("log", []byte("def")); err != nil || n != 6 {
+		t.Fatalf("expect length 6, got %d (%v)", n, err)
+	}
+	if view.String() != "abc" {
+		t.Fatalf("append must not modify returned views, got %q", view)
+	}
+	if _, err := g.Append("log", []byte("g")); err != ErrAppendTooLarge {
+		t.Fatalf("expect ErrAppendTooLarge, got %v", err)
+	}
+	if view, err := g.Get("log"); err != nil || view.String() != "abcdef" {
+		t.Fatalf("expect abcdef, got %q (%v)", view, err)
+	}
+}
+
+func TestGetContext(t *testing.T) {
+	g := NewGroup("ctx", 2<<10, ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := g.GetContext(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect deadline exceeded, got %v", err)
+	}
+}
+
+func TestShardedGroup(t *testing.T) {
+	g := NewGroup("sharded", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }),
+		WithShards(4))
+
+	if len(g.mainCache.shards) != 4 || g.mainCache.shards[0].cacheBytes != (2<<10)/4 {
+		t.Fatalf("expect 4 shards with a quarter of the budget each")
+	}
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		if view, err := g.Get(k); err != nil || view.String() != k {
+			t.Fatalf("expect %s, got %q (%v)", k, view, err)
+		}
+		if _, ok := g.mainCache.get(k); !ok {
+			t.Fatalf("expect %s to be cached", k)
+		}
+	}
+}
+
+func TestAutoShards(t *testing.T) {
+	cases := []struct {
+		cacheBytes int64
+		procs      int
+		want       int
+	}{
+		{2 << 10, 8, 1},      // 小容量保持单分片
+		{0, 8, 1},            // 不缓存时不分片
+		{64 << 20, 8, 16},    // 受每分片最小容量限制
+		{1 << 30, 8, 32},     // GOMAXPROCS*4
+		{1 << 30, 3, 16},     // 向上取整到2的幂
+		{64 << 30, 128, 256}, // 分片数上限
+	}
+	for _, c := range cases {
+		if got := autoShards(c.cacheBytes, c.procs); got != c.want {
+			t.Errorf("autoShards(%d, %d) = %d, want %d", c.cacheBytes, c.procs, got, c.want)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	g := NewGroup("stats", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			if v, ok := db[key]; ok {
+				return []byte(v), nil
+			}
+			return nil, fmt.Errorf("%s not exist", key)
+		}))
+
+	g.Get("Tom")
+	g.Get("Tom")
+	g.Get("unknown")
+
+	s := g.Stats()
+	if s.Gets != 3 || s.Hits != 1 || s.Misses != 2 || s.Loads != 2 {
+		t.Fatalf("unexpected get counters %+v", s)
+	}
+	if s.LocalLoads != 1 || s.LocalLoadErrs != 1 || s.PeerLoads != 0 {
+		t.Fatalf("unexpected load counters %+v", s)
+	}
+	if s.Items != 1 || s.Bytes != int64(len("Tom")+len("630")) {
+		t.Fatalf("unexpected cache counters %+v", s)
+	}
+}
+
+func TestNegativeCache(t *testing.T) {
+	loads := 0
+	g := NewGroup("negative", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			return nil, fmt.Errorf("%s not exist", key)
+		}), WithNegativeCache(20*time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.Get("missing"); err == nil {
+			t.Fatal("expect error for missing key")
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expect 1 load within the negative ttl, got %d", loads)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	g.Get("missing")
+	if loads != 2 {
+		t.Fatalf("expect reload after the negative ttl, got %d", loads)
+	}
+
+	g.Set("missing", []byte("now exists"))
+	if view, err := g.Get("missing"); err != nil || view.String() != "now exists" {
+		t.Fatalf("expect Set to override the negative entry, got %q (%v)", view, err)
+	}
+}
+
+func TestAdaptiveTTL(t *testing.T) {
+	a := newAdaptiveTTL(time.Second, 8*time.Second, 0)
+	steps := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"v1", time.Second},     // 首次加载
+		{"v1", 2 * time.Second}, // 未变化，翻倍
+		{"v1", 4 * time.Second}, // 未变化，翻倍
+		{"v1", 8 * time.Second}, // 达到上限
+		{"v1", 8 * time.Second}, // 保持上限
+		{"v2", 4 * time.Second}, // 变化，减半
+		{"v3", 2 * time.Second}, // 变化，减半
+		{"v4", time.Second},     // 达到下限
+		{"v5", time.Second},     // 保持下限
+	}
+	for i, s := range steps {
+		if got := a.next("k", []byte(s.value)); got != s.want {
+			t.Fatalf("step %d: expect ttl %v, got %v", i, s.want, got)
+		}
+	}
+	for i := 0; i < 1000; i++ {
+		a.next(strconv.Itoa(i), []byte("v"))
+	}
+	if b := a.states.Bytes(); b > minAdaptiveStateBytes {
+		t.Fatalf("expect a zero budget to fall back to %d bytes of state, got %d", minAdaptiveStateBytes, b)
+	}
+
+	loads := 0
+	g := NewGroup("adaptive", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			return []byte("stable"), nil
+		}), WithAdaptiveTTL(20*time.Millisecond, time.Minute))
+	g.Get("k")
+	time.Sleep(30 * time.Millisecond)
+	g.Get("k") // 已过期，重新回源，值未变化：有效期延长到40ms
+	time.Sleep(30 * time.Millisecond)
+	g.Get("k")
+	if loads != 2 {
+		t.Fatalf("expect the stable key to outlive its initial ttl, got %d loads", loads)
+	}
+}
+
+func TestBackground(t *testing.T) {
+	g := NewGroup("background", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }), WithBackgroundLimit(1))
+
+	started := make(chan struct{})
+	if !g.bg.goTask(func(ctx context.Context) { close(started); <-ctx.Done() }) {
+		t.Fatal("expect the first task to start")
+	}
+	<-started
+	if g.bg.goTask(func(context.Context) {}) {
+		t.Fatal("expect the task over the limit to be dropped")
+	}
+	ticks := make(chan struct{}, 1)
+	g.bg.loop(time.Millisecond, func(context.Context) {
+		select {
+		case ticks <- struct{}{}:
+		default:
+		}
+	})
+	<-ticks
+
+	if s := g.Stats(); s.BackgroundGoroutines != 2 || s.BackgroundDropped != 1 {
+		t.Fatalf("expect 2 goroutines and 1 dropped task, got %+v", s)
+	}
+	g.Close()
+	if s := g.Stats(); s.BackgroundGoroutines != 0 {
+		t.Fatalf("expect Close to stop all goroutines, %d left", s.BackgroundGoroutines)
+	}
+	if g.bg.goTask(func(context.Context) {}) {
+		t.Fatal("expect no tasks to start after Close")
+	}
+
+	// 与Close并发启动的任务要么被丢弃，要么在Close返回前结束
+	for i := 0; i < 50; i++ {
+		bg := newBackground(defaultBackgroundLimit)
+		var closed, late atomic.Bool
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for k := 0; k < 20; k++ {
+					bg.goTask(func(context.Context) {
+						if closed.Load() {
+							late.Store(true)
+						}
+					})
+				}
+			}()
+		}
+		bg.close()
+		closed.Store(true)
+		wg.Wait()
+		if late.Load() {
+			t.Fatal("expect no task to run after close returned")
+		}
+	}
+}
+
+func TestByteViewReader(t *testing.T) {
+	v := ByteView{b: []byte("streamed value")}
+	r := v.Reader()
+	if _, err := r.Seek(9, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil || string(b) != "value" {
+		t.Fatalf("expect value, got %q (%v)", b, err)
+	}
+}
+
+func TestGetWithInfo(t *testing.T) {
+	g := NewGroup("provenance", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	g.Set("written", []byte("v"))
+
+	cases := []struct {
+		key    string
+		source Source
+		hit    bool
+	}{
+		{"loaded", SourceLoad, false},
+		{"loaded", SourceLoad, true},
+		{"written", SourceSet, true},
+	}
+	for _, c := range cases {
+		_, info, err := g.GetWithInfo(context.Background(), c.key)
+		if err != nil || info.Source != c.source || info.Hit != c.hit {
+			t.Fatalf("%s: expect source %v hit %v, got %+v (%v)", c.key, c.source, c.hit, info, err)
+		}
+	}
+}
+
+func TestByteViewSlice(t *testing.T) {
+	v := ByteView{b: []byte("hello world")}
+	if v.At(4) != 'o' {
+		t.Fatalf("expect o at 4, got %q", v.At(4))
+	}
+	if s := v.Slice(0, 5); s.String() != "hello" {
+		t.Fatalf("expect hello, got %q", s)
+	}
+	if s := v.SliceFrom(6); !s.Equal(ByteView{b: []byte("world")}) {
+		t.Fatalf("expect world, got %q", s)
+	}
+	if v.Equal(v.Slice(0, 5)) {
+		t.Fatal("expect views with different content to differ")
+	}
+}
+
+func TestSnapshotView(t *testing.T) {
+	g := NewGroup("snapshot", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }), WithShards(4))
+	for _, k := range []string{"a", "b", "c"} {
+		g.Set(k, []byte(k))
+	}
+	g.SetWithTTL("expired", []byte("x"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	snap := g.SnapshotView()
+	g.Set("d", []byte("d"))
+	g.Remove("a")
+
+	got := make(map[string]string)
+	snap.Range(func(key string, value ByteView) bool {
+		got[key] = value.String()
+		return true
+	})
+	want := map[string]string{"a": "a", "b": "b", "c": "c"}
+	if !reflect.DeepEqual(got, want) || snap.Len() != 3 {
+		t.Fatalf("expect snapshot %v, got %v", want, got)
+	}
+}
+
+func TestPrefixStats(t *testing.T) {
+	g := NewGroup("prefix", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("1234"), nil }),
+		WithPrefixStats("user:", "user:vip:", "feed:"))
+
+	for _, k := range []string{"user:1", "user:1", "user:vip:1", "feed:1", "other"} {
+		g.Get(k)
+	}
+
+	want := map[string]PrefixStats{
+		"user:":     {Gets: 2, Hits: 1, Bytes: 10, Items: 1},
+		"user:vip:": {Gets: 1, Hits: 0, Bytes: 14, Items: 1},
+		"feed:":     {Gets: 1, Hits: 0, Bytes: 10, Items: 1},
+		"":          {Gets: 1, Hits: 0, Bytes: 9, Items: 1},
+	}
+	if got := g.PrefixStats(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expect %+v, got %+v", want, got)
+	}
+
+	// 计入每条目开销时与LRU的占用一致
+	g = NewGroup("prefix-overhead", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("1234"), nil }),
+		WithPrefixStats("user:"), WithEntryOverhead(100))
+	g.Get("user:1")
+	if got, s := g.PrefixStats()["user:"].Bytes, g.Stats(); got != 110 || got != s.Bytes {
+		t.Fatalf("expect 110 bytes matching the cache, got %d and %d", got, s.Bytes)
+	}
+
+	// 按编码后的长度计，已过期未清除的条目与hotCache副本也计入
+	g = NewGroup("prefix-encoded", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("1234"), nil }),
+		WithPrefixStats("user:"), WithTransformers(envelope("v1:")))
+	g.Get("user:1")
+	g.SetWithTTL("user:2", []byte("1234"), time.Nanosecond)
+	g.addHot("user:1", ByteView{b: []byte("1234")})
+	time.Sleep(time.Millisecond)
+	got, s := g.PrefixStats()["user:"], g.Stats()
+	if got.Bytes != 3*13 || got.Bytes != s.Bytes || got.Items != s.Items {
+		t.Fatalf("expect %d bytes matching the cache, got %+v and %+v", 3*13, got, s)
+	}
+}
+
+func TestMaxEntryBytes(t *testing.T) {
+	loads := 0
+	g := NewGroup("max-entry", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			loads++
+			return []byte(key), nil
+		}), WithMaxEntryBytes(4))
+
+	for i := 0; i < 2; i++ {
+		if v, err := g.Get("too-long"); err != nil || v.String() != "too-long" {
+			t.Fatalf("expect the oversized value to be returned, got %q (%v)", v, err)
+		}
+	}
+	if loads != 2 {
+		t.Fatalf("expect the oversized value not to be cached, got %d loads", loads)
+	}
+
+	g.Set("k", []byte("ok"))
+	g.Set("k", []byte("too large"))
+	if v, _ := g.Get("k"); v.String() != "k" {
+		t.Fatalf("expect the stale value to be dropped, got %q", v)
+	}
+	if s := g.Stats(); s.Oversized != 3 {
+		t.Fatalf("expect 3 oversized values, got %d", s.Oversized)
+	}
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	var version atomic.Int64
+	g := NewGroup("stale", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			return []byte(strconv.FormatInt(version.Add(1), 10)), nil
+		}), WithAdaptiveTTL(10*time.Millisecond, 10*time.Millisecond),
+		WithStaleWhileRevalidate(time.Minute))
+
+	if v, _ := g.Get("k"); v.String() != "1" {
+		t.Fatalf("expect 1, got %q", v)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if v, _ := g.Get("k"); v.String() != "1" {
+		t.Fatalf("expect the stale value to be served, got %q", v)
+	}
+	for i := 0; i < 100; i++ {
+		if v, _ := g.Get("k"); v.String() == "2" {
+			if s := g.Stats(); s.StaleServed < 1 {
+				t.Fatalf("expect stale serves to be counted, got %+v", s)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expect the background refresh to replace the stale value")
+}
+
+func TestJanitor(t *testing.T) {
+	g := NewGroup("janitor", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }), WithJanitor(time.Millisecond))
+	defer g.Close()
+
+	g.SetWithTTL("short", []byte("v"), time.Millisecond)
+	g.Set("forever", []byte("v"))
+	for i := 0; i < 100; i++ {
+		if s := g.Stats(); s.ExpiredSwept == 1 {
+			if s.Items != 1 {
+				t.Fatalf("expect only the unexpired entry to remain, got %d items", s.Items)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expect the janitor to sweep the expired entry")
+}
+
+func TestOnEvictedReason(t *testing.T) {
+	reasons := make(chan EvictReason, 10)
+	g := NewGroup("evict-reason", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }),
+		WithJanitor(time.Millisecond),
+		WithOnEvicted(func(key string, _ ByteView, reason EvictReason) {
+			if key == "short" {
+				reasons <- reason
+			}
+		}))
+	defer g.Close()
+
+	g.Set("short", []byte("v1"))
+	g.SetWithTTL("short", []byte("v2"), time.Millisecond)
+	for _, expect := range []EvictReason{EvictReplaced, EvictExpired} {
+		select {
+		case got := <-reasons:
+			if got != expect {
+				t.Fatalf("expect reason %v, got %v", expect, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expect an eviction with reason %v", expect)
+		}
+	}
+}
+
+func TestCacheRemove(t *testing.T) {
+	var evicted []string
+	c := newShardedCache(2<<10, 4, func(key string, _ ByteView, _ EvictReason) { evicted = append(evicted, key) })
+	c.add("k1", ByteView{b: []byte("v1")})
+	c.add("k2", ByteView{b: []byte("v2")})
+
+	if !c.remove("k1") || c.remove("k1") || c.remove("missing") {
+		t.Fatal("expect remove to report whether the key existed")
+	}
+	if c.items() != 1 || c.bytes() != int64(len("k2v2")) {
+		t.Fatalf("expect the removed entry to be unaccounted, got %d items, %d bytes", c.items(), c.bytes())
+	}
+	if !reflect.DeepEqual(evicted, []string{"k1"}) {
+		t.Fatalf("expect the eviction callback for k1, got %v", evicted)
+	}
+}
+
+func TestEntryOverhead(t *testing.T) {
+	g := NewGroup("entry-overhead", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }), WithEntryOverhead(100))
+	g.Set("k", []byte("v"))
+	if s := g.Stats(); s.Bytes != int64(len("kv"))+100 {
+		t.Fatalf("expect the overhead to be accounted, got %d bytes", s.Bytes)
+	}
+}
+
+func TestClear(t *testing.T) {
+	g := NewGroup("clear", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	g.Set("a", []byte("1"))
+	g.Get("b")
+	g.Clear()
+
+	if s := g.Stats(); s.Items != 0 || s.Bytes != 0 {
+		t.Fatalf("expect an empty group after Clear, got %d items, %d bytes", s.Items, s.Bytes)
+	}
+	if v, _ := g.Get("a"); v.String() != "a" {
+		t.Fatalf("expect a reload after Clear, got %q", v)
+	}
+}
+
+func TestNegativeCacheFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "negative")
+	loads := 0
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return nil, fmt.Errorf("%s not exist", key)
+	})
+
+	g := NewGroup("negative-file", 2<<10, getter, WithNegativeCache(time.Minute), WithNegativeCacheFile(path))
+	g.Get("missing")
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟重启
+	g = NewGroup("negative-file", 2<<10, getter, WithNegativeCache(time.Minute), WithNegativeCacheFile(path))
+	if _, err := g.Get("missing"); err == nil {
+		t.Fatal("expect the restored negative entry to be returned")
+	}
+	if loads != 1 {
+		t.Fatalf("expect no origin load after restart, got %d loads", loads)
+	}
+
+	// 替换同名Group时旧Group先写回文件，新Group加载到的包含它的负缓存
+	g.Get("missing2")
+	g = NewGroup("negative-file", 2<<10, getter, WithNegativeCache(time.Minute), WithNegativeCacheFile(path))
+	defer g.Close()
+	if _, err := g.Get("missing2"); err == nil || loads != 2 {
+		t.Fatalf("expect the replaced group's negative entry to be loaded, got %d loads (%v)", loads, err)
+	}
+}
+
+func TestSnapshotSaveLoad(t *testing.T) {
+	loads := 0
+	getter := GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(key), nil
+	})
+	g := NewGroup("snapshot-file", 2<<10, getter)
+	g.Get("k1")
+	g.SetWithTTL("k2", []byte("v2"), time.Hour)
+	g.SetWithTTL("gone", []byte("x"), time.Nanosecond)
+
+	var buf bytes.Buffer
+	if err := g.SaveSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟重启
+	g = NewGroup("snapshot-file", 2<<10, getter)
+	if err := g.LoadSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := g.Get("k1"); err != nil || v.String() != "k1" || loads != 1 {
+		t.Fatalf("expect k1 from the snapshot without a load, got %q, %v, %d loads", v, err, loads)
+	}
+	if _, info, _ := g.GetWithInfo(context.Background(), "k2"); !info.Hit || info.Expire.IsZero() || info.Source != SourceSnapshot {
+		t.Fatalf("expect k2 to keep its expiry and be marked as restored, got %+v", info)
+	}
+	if s := g.Stats(); s.Items != 2 {
+		t.Fatalf("expect the expired entry to be skipped, got %d items", s.Items)
+	}
+}
+
+func TestL2(t *testing.T) {
+	l2, err := NewDirL2(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	loads := 0
+	g := NewGroup("l2", 30, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte("0123456789"), nil
+	}), WithL2(l2))
+	for i := 0; i < 5; i++ {
+		g.Get("k" + strconv.Itoa(i))
+	}
+	// 写回在后台执行
+	for i := 0; i < 100; i++ {
+		if _, _, ok := l2.Get("k0"); ok {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	v, info, err := g.GetWithInfo(context.Background(), "k0")
+	if err != nil || v.String() != "0123456789" || info.Source != SourceL2 {
+		t.Fatalf("expect k0 from L2, got %q, %v, %v", v, info.Source, err)
+	}
+	if s := g.Stats(); loads != 5 || s.L2Hits != 1 {
+		t.Fatalf("expect the evicted entry to be read back from L2, got %d loads, %+v", loads, s)
+	}
+
+	if _, _, ok := l2.Get("k0"); ok {
+		t.Fatal("expect the L2 copy to be dropped once read back")
+	}
+
+	// 本地写入后L2中不能留有旧值：新值过期后应回源，而不是读到L2中的旧值
+	g.SetWithTTL("k0", []byte("v2"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if v, _ := g.Get("k0"); v.String() != "0123456789" || loads != 6 {
+		t.Fatalf("expect the expired value to be reloaded, got %q after %d loads", v, loads)
+	}
+
+	g.Remove("k1")
+	g.Get("k1")
+	if loads != 7 {
+		t.Fatalf("expect Remove to drop the L2 copy, got %d loads", loads)
+	}
+}
+
+func TestL2Update(t *testing.T) {
+	l2, err := NewDirL2(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewGroup("l2-update", 30, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("0123456789"), nil
+	}), WithL2(l2))
+	defer g.Close()
+	if n, err := g.Increment("ctr", 41); err != nil || n != 41 {
+		t.Fatalf("expect 41, got %d (%v)", n, err)
+	}
+	for i := 0; i < 5; i++ {
+		g.Get("k" + strconv.Itoa(i))
+	}
+	if _, ok := g.mainCache.get("ctr"); ok {
+		t.Fatal("expect ctr to be evicted")
+	}
+
+	// 无论写回是否已完成，被淘汰的计数器都应在原值上累加
+	if n, err := g.Increment("ctr", 1); err != nil || n != 42 {
+		t.Fatalf("expect the evicted counter to continue from 41, got %d (%v)", n, err)
+	}
+	if v, err := g.Get("ctr"); err != nil || v.String() != "42" {
+		t.Fatalf("expect 42, got %q (%v)", v, err)
+	}
+}
+
+func TestL2GetMulti(t *testing.T) {
+	l2, err := NewDirL2(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	origin := &batchDB{}
+	g := NewGroup("l2-multi", 2<<10, origin, WithL2(l2))
+	defer g.Close()
+	if err := l2.Put("Tom", []byte("630"), time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	views, err := g.GetMulti([]string{"Tom", "Sam"})
+	if err != nil || views["Tom"].String() != "630" || views["Tom"].src != SourceL2 || views["Sam"].String() != "567" {
+		t.Fatalf("expect Tom from L2 and Sam from the origin, got %v (%v)", views, err)
+	}
+	if s := g.Stats(); origin.calls != 1 || s.L2Hits != 1 {
+		t.Fatalf("expect one batched load and one L2 hit, got %d loads, %+v", origin.calls, s)
+	}
+
+	g.mainCache.clear()
+	l2.Put("Jack", []byte("589"), time.Time{})
+	if views, err := g.GetMulti([]string{"Jack"}); err != nil || views["Jack"].String() != "589" || origin.calls != 1 {
+		t.Fatalf("expect a batch served from L2 not to call the origin, got %v, %d loads (%v)", views, origin.calls, err)
+	}
+}
+
+func TestScheduleRefresh(t *testing.T) {
+	var version atomic.Int64
+	g := NewGroup("schedule", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key + strconv.FormatInt(version.Load(), 10)), nil
+	}))
+	defer g.Close()
+
+	g.Get("rate:usd")
+	g.Get("flag:beta")
+	version.Store(1)
+	stop := g.ScheduleRefreshMatching("rate:*", time.Millisecond)
+	defer stop()
+
+	for i := 0; i < 100; i++ {
+		if v, _ := g.Get("rate:usd"); v.String() == "rate:usd1" {
+			if v, _ := g.Get("flag:beta"); v.String() != "flag:beta0" {
+				t.Fatalf("expect unmatched keys to be left alone, got %q", v)
+			}
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expect the scheduled refresh to reload rate:usd")
+}
+
+func TestScheduleRefreshBypassesCaches(t *testing.T) {
+	var exists atomic.Bool
+	g := NewGroup("schedule-negative", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if !exists.Load() {
+			return nil, ErrNotFound
+		}
+		return []byte("v"), nil
+	}), WithNegativeCache(time.Hour))
+	defer g.Close()
+
+	if _, err := g.Get("k"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound, got %v", err)
+	}
+	exists.Store(true)
+	stop := g.ScheduleRefresh([]string{"k"}, time.Millisecond)
+	defer stop()
+
+	for i := 0; i < 100; i++ {
+		if v, err := g.Get("k"); err == nil && v.String() == "v" {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expect the scheduled refresh to replace the negative entry")
+}
+
+func TestOriginProbe(t *testing.T) {
+	var healthy atomic.Bool
+	var loads atomic.Int64
+	g := NewGroup("origin-probe", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads.Add(1)
+		return []byte("fresh"), nil
+	}), WithOriginProbe(func(context.Context) error {
+		if healthy.Load() {
+			return nil
+		}
+		return errors.New("down")
+	}, time.Millisecond))
+	defer g.Close()
+
+	waitFor := func(want bool) {
+		for i := 0; i < 100 && g.OriginHealthy() != want; i++ {
+			time.Sleep(time.Millisecond)
+		}
+		if g.OriginHealthy() != want {
+			t.Fatalf("expect OriginHealthy to become %v", want)
+		}
+	}
+	g.SetWithTTL("k", []byte("stale"), time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	waitFor(false)
+	if v, err := g.Get("k"); err != nil || v.String() != "stale" || loads.Load() != 0 {
+		t.Fatalf("expect the expired value while the origin is down, got %q, %v, %d loads", v, err, loads.Load())
+	}
+
+	healthy.Store(true)
+	waitFor(true)
+	if v, _ := g.Get("k"); v.String() != "fresh" {
+		t.Fatalf("expect a reload once the origin recovers, got %q", v)
+	}
+}
+
+func TestOriginBudget(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) { return []byte(key), nil })
+	heavy := NewGroup("budget-heavy", 2<<10, getter, WithOriginWeight(3))
+	light := NewGroup("budget-light", 2<<10, getter)
+	SetOriginBudget(1000, 100)
+	defer SetOriginBudget(0, 0)
+
+	if r := heavy.originLimiter.Limit() / light.originLimiter.Limit(); r < 2.99 || r > 3.01 {
+		t.Fatalf("expect a 3:1 split, got %v", r)
+	}
+	light.SetOriginWeight(6)
+	if r := light.originLimiter.Limit() / heavy.originLimiter.Limit(); r < 1.99 || r > 2.01 {
+		t.Fatalf("expect reweighting to rebalance, got %v", r)
+	}
+
+	SetOriginBudget(0.001, 1)
+	light.Get("a") // 用掉唯一的令牌
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := light.GetContext(ctx, "b"); err == nil {
+		t.Fatal("expect the exhausted budget to hold the load back")
+	}
+	if _, err := light.Get("a"); err != nil {
+		t.Fatalf("expect cache hits to bypass the budget, got %v", err)
+	}
+}
+
+func TestWait(t *testing.T) {
+	g := NewGroup("wait", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+
+	got := make(chan string)
+	go func() {
+		v, err := g.Wait(context.Background(), "job")
+		if err != nil {
+			got <- err.Error()
+			return
+		}
+		got <- v.String()
+	}()
+	time.Sleep(5 * time.Millisecond)
+	g.Set("job", []byte("done"))
+	select {
+	case v := <-got:
+		if v != "done" {
+			t.Fatalf("expect the waiter to see the new value, got %q", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect Set to wake the waiter")
+	}
+
+	if v, err := g.Wait(context.Background(), "job"); err != nil || v.String() != "done" {
+		t.Fatalf("expect a present key to return at once, got %q, %v", v, err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := g.Wait(ctx, "never"); err != context.DeadlineExceeded {
+		t.Fatalf("expect the deadline to end the wait, got %v", err)
+	}
+	if n := g.waiters.n.Load(); n != 0 || len(g.waiters.m) != 0 {
+		t.Fatalf("expect waiters to be released, got %d", n)
+	}
+}
+
+func TestZeroCacheBytes(t *testing.T) {
+	loads := 0
+	g := NewGroup("zero-bytes", 0, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(key), nil
+	}))
+	for i := 0; i < 3; i++ {
+		if v, err := g.Get("k"); err != nil || v.String() != "k" {
+			t.Fatalf("expect read-through to work, got %q, %v", v, err)
+		}
+	}
+	g.Set("s", []byte("v"))
+	if s := g.Stats(); loads != 3 || s.Items != 0 || s.Bytes != 0 {
+		t.Fatalf("expect nothing to be cached, got %d loads, %+v", loads, s)
+	}
+	if _, err := g.Increment("n", 1); !errors.Is(err, ErrNoCapacity) {
+		t.Fatalf("expect Increment to report ErrNoCapacity, got %v", err)
+	}
+	if ok, err := g.CAS("s", ByteView{}, []byte("v")); ok || !errors.Is(err, ErrNoCapacity) {
+		t.Fatalf("expect CAS to report ErrNoCapacity, got %v, %v", ok, err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expect negative cacheBytes to panic")
+		}
+	}()
+	NewGroup("negative-bytes", -1, GetterFunc(func(key string) ([]byte, error) { return nil, nil }))
+}
+
+// envelope 为值加上版本前缀，用于测试转换管道
+type envelope string
+
+func (e envelope) Encode(_ string, value []byte) ([]byte, error) {
+	return append([]byte(e), value...), nil
+}
+
+func (e envelope) Decode(key string, stored []byte) ([]byte, error) {
+	if !bytes.HasPrefix(stored, []byte(e)) {
+		return nil, fmt.Errorf("%s: missing %s envelope", key, e)
+	}
+	return stored[len(e):], nil
+}
+
+func TestTransformers(t *testing.T) {
+	loads := 0
+	g := NewGroup("transform", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(key), nil
+	}), WithTransformers(envelope("v1:"), envelope("gz:")))
+
+	if v, err := g.Get("k"); err != nil || v.String() != "k" {
+		t.Fatalf("expect the decoded value, got %q, %v", v, err)
+	}
+	if v, _ := g.Get("k"); v.String() != "k" || loads != 1 {
+		t.Fatalf("expect a decoded cache hit, got %q after %d loads", v, loads)
+	}
+	if s := g.Stats(); s.Bytes != int64(len("k"+"gz:v1:k")) {
+		t.Fatalf("expect the encoded value to be stored, got %d bytes", s.Bytes)
+	}
+	if n, err := g.Increment("n", 2); err != nil || n != 2 {
+		t.Fatalf("expect Increment to see decoded values, got %d, %v", n, err)
+	}
+	if n, _ := g.Increment("n", 3); n != 5 {
+		t.Fatalf("expect 5, got %d", n)
+	}
+
+	g.mainCache.add("k", ByteView{b: []byte("legacy")}) // 无法解码的旧格式
+	if v, _ := g.Get("k"); v.String() != "k" || loads != 2 {
+		t.Fatalf("expect an undecodable entry to be reloaded, got %q after %d loads", v, loads)
+	}
+}
+
+func TestEnvelopeMigration(t *testing.T) {
+	v1 := NewEnvelope(1)
+	g := NewGroup("envelope", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte("fresh"), nil
+	}), WithTransformers(NewEnvelope(3).
+		Migrate(1, func(_ string, old []byte) ([]byte, error) { return append(old, "+v2"...), nil }).
+		Migrate(2, func(_ string, old []byte) ([]byte, error) { return append(old, "+v3"...), nil })))
+
+	// 旧版本代码写入的值
+	stored, _ := v1.Encode("k", []byte("old"))
+	g.mainCache.add("k", ByteView{b: stored})
+	if v, err := g.Get("k"); err != nil || v.String() != "old+v2+v3" {
+		t.Fatalf("expect the value to be migrated, got %q, %v", v, err)
+	}
+	if raw, _ := g.mainCache.get("k"); !bytes.Equal(raw.b, []byte("\x00\xfe\x03old+v2+v3")) {
+		t.Fatalf("expect the migrated value to be rewritten, got %q", raw.b)
+	}
+
+	// 没有迁移路径的版本被丢弃并重新回源
+	stored, _ = NewEnvelope(7).Encode("k", []byte("future"))
+	g.mainCache.add("k", ByteView{b: stored})
+	if v, _ := g.Get("k"); v.String() != "fresh" {
+		t.Fatalf("expect a newer version to be reloaded, got %q", v)
+	}
+}
+
+func TestSetCacheBytes(t *testing.T) {
+	g := NewGroup("resize", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("0123456789"), nil }))
+	for i := 0; i < 10; i++ {
+		g.Get(strconv.Itoa(i))
+	}
+
+	g.SetCacheBytes(30)
+	if s := g.Stats(); s.Bytes > 30 || s.Items != 2 {
+		t.Fatalf("expect the group to shrink to 30 bytes, got %d items, %d bytes", s.Items, s.Bytes)
+	}
+	g.SetCacheBytes(2 << 10)
+	for i := 0; i < 10; i++ {
+		g.Get(strconv.Itoa(i))
+	}
+	if s := g.Stats(); s.Items != 10 {
+		t.Fatalf("expect the group to grow back, got %d items", s.Items)
+	}
+}
+
+func TestRandSource(t *testing.T) {
+	// 相同种子下热点缓存的概率写入结果一致
+	hotKeys := func() []string {
+		g := NewGroup("rand", 2<<10, GetterFunc(
+			func(key string) ([]byte, error) { return []byte(key), nil }),
+			WithRandSource(rand.NewSource(42)))
+		var keys []string
+		for i := 0; i < 50; i++ {
+			key := strconv.Itoa(i)
+			g.getFromPeer(context.Background(), echoPeer{}, key)
+			if _, ok := g.hotCache.get(key); ok {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	if a, b := hotKeys(), hotKeys(); !reflect.DeepEqual(a, b) || len(a) == 0 {
+		t.Fatalf("expect identical hot keys for the same seed, got %v and %v", a, b)
+	}
+}
+
+// echoPeer 将key原样作为值返回的远端节点
+type echoPeer struct{ PeerGetter }
+
+func (echoPeer) Get(_ context.Context, _ string, key string) ([]byte, error) {
+	return []byte(key), nil
+}
+
+// heavyGetter 以"report/"开头的key为重量级key的Getter
+type heavyGetter struct {
+	running, peak atomic.Int32
+}
+
+func (h *heavyGetter) Get(key string) ([]byte, error) {
+	n := h.running.Add(1)
+	defer h.running.Add(-1)
+	for p := h.peak.Load(); n > p && !h.peak.CompareAndSwap(p, n); p = h.peak.Load() {
+	}
+	time.Sleep(20 * time.Millisecond)
+	return []byte(key), nil
+}
+
+func (h *heavyGetter) LoadPolicy(key string) LoadPolicy {
+	if len(key) > 7 && key[:7] == "report/" {
+		return LoadPolicy{Class: "heavy"}
+	}
+	return LoadPolicy{}
+}
+
+func TestKeyPolicyLoadClass(t *testing.T) {
+	getter := &heavyGetter{}
+	g := NewGroup("keypolicy", 2<<10, getter, WithLoadClass("heavy", 1))
+
+	done := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		go func(i int) {
+			_, err := g.Get("report/" + strconv.Itoa(i))
+			done <- err
+		}(i)
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-done; err != nil {
+			t.Fatalf("expect heavy keys to load, got %v", err)
+		}
+	}
+	if p := getter.peak.Load(); p != 1 {
+		t.Fatalf("expect at most 1 heavy load at a time, got %d", p)
+	}
+
+	// 已满的类别在ctx结束时放弃等待
+	g.loadClasses["heavy"] <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := g.GetContext(ctx, "report/x"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect the queued heavy load to time out, got %v", err)
+	}
+	if v, err := g.Get("cheap"); err != nil || v.String() != "cheap" {
+		t.Fatalf("expect cheap keys to bypass the heavy class, got %q, %v", v, err)
+	}
+}
+
+func TestShadowSample(t *testing.T) {
+	g := NewGroup("shadow", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("origin"), nil }),
+		WithShadowSample(1))
+	g.Set("same", []byte("origin"))
+	g.Set("stale", []byte("cached"))
+
+	for _, key := range []string{"same", "stale"} {
+		if v, err := g.Get(key); err != nil || v.String() == "" {
+			t.Fatalf("expect the cached value to be served, got %q, %v", v, err)
+		}
+	}
+	deadline := time.Now().Add(time.Second)
+	for g.Stats().ShadowLoads < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if s := g.Stats(); s.ShadowLoads != 2 || s.ShadowMismatches != 1 || s.ShadowErrors != 0 {
+		t.Fatalf("expect 2 shadow loads with 1 mismatch, got %+v", s)
+	}
+	if v, _ := g.Get("stale"); v.String() != "cached" {
+		t.Fatalf("expect shadow loads not to overwrite the cache, got %q", v)
+	}
+}
+
+func TestRemoveDuringLoad(t *testing.T) {
+	started, unblock := make(chan struct{}), make(chan struct{})
+	g := NewGroup("removeduringload", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			close(started)
+			<-unblock
+			return []byte("before delete"), nil
+		}))
+
+	done := make(chan ByteView)
+	go func() {
+		v, _ := g.Get("k")
+		done <- v
+	}()
+	<-started
+	if err := g.Remove("k"); err != nil {
+		t.Fatal(err)
+	}
+	close(unblock)
+
+	// 已在等待的调用方照常拿到结果，但结果不回填缓存
+	if v := <-done; v.String() != "before delete" {
+		t.Fatalf("expect the in-flight load to return its value, got %q", v)
+	}
+	if _, ok := g.mainCache.get("k"); ok {
+		t.Fatal("expect a load raced by Remove not to populate the cache")
+	}
+	if s := g.Stats(); s.LoadsInvalidated != 1 {
+		t.Fatalf("expect 1 invalidated load, got %d", s.LoadsInvalidated)
+	}
+}
+
+// routePicker 记录被路由的key，所有key都属于本节点
+type routePicker struct {
+	mu     sync.Mutex
+	routed []string
+}
+
+func (p *routePicker) PickPeer(key string) (PeerGetter, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.routed = append(p.routed, key)
+	return nil, false
+}
+
+func (p *routePicker) AllPeers() []PeerGetter { return nil }
+
+func TestRoutingKey(t *testing.T) {
+	g := NewGroup("routing", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }),
+		WithRoutingKey(func(key string) string {
+			user, _, _ := strings.Cut(key, "/")
+			return user
+		}))
+	picker := &routePicker{}
+	g.RegisterPeers(picker)
+
+	for _, key := range []string{"user42/profile", "user42/orders"} {
+		if v, err := g.Get(key); err != nil || v.String() != key {
+			t.Fatalf("expect %s to remain a distinct entry, got %q, %v", key, v, err)
+		}
+	}
+	if expect := []string{"user42", "user42"}; !reflect.DeepEqual(picker.routed, expect) {
+		t.Fatalf("expect keys to be routed by user, got %v", picker.routed)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	debug := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	g := NewGroup("logger", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }),
+		WithLogger(debug))
+
+	g.Get("k")
+	g.Get("k")
+	if out := buf.String(); !strings.Contains(out, `level=DEBUG msg="cache hit" group=logger key=k`) {
+		t.Fatalf("expect a structured debug entry for the hit, got %q", out)
+	}
+
+	buf.Reset()
+	g.log = slog.New(slog.NewTextHandler(&buf, nil)) // 默认Info级别
+	g.Get("k")
+	if buf.Len() != 0 {
+		t.Fatalf("expect hits to be silent at Info level, got %q", buf.String())
+	}
+}
+
+// brokenCodec 编码总是失败，模拟缓存层故障
+type brokenCodec struct{}
+
+func (brokenCodec) Encode(key string, _ []byte) ([]byte, error) {
+	return nil, fmt.Errorf("%s: codec unavailable", key)
+}
+
+func (brokenCodec) Decode(_ string, stored []byte) ([]byte, error) { return stored, nil }
+
+func TestBypassOnErrors(t *testing.T) {
+	var alerts []bool
+	loads := 0
+	g := NewGroup("bypass", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads++
+		return []byte(key), nil
+	}), WithTransformers(brokenCodec{}),
+		WithBypassOnErrors(2, time.Minute, 50*time.Millisecond, func(_ string, bypassing bool, _ error) {
+			alerts = append(alerts, bypassing)
+		}))
+
+	g.Get("a")
+	g.Get("b")
+	if len(alerts) != 1 || !alerts[0] {
+		t.Fatalf("expect the bypass to trip after 2 cache errors, got alerts %v", alerts)
+	}
+	if v, err := g.Get("c"); err != nil || v.String() != "c" || loads != 3 {
+		t.Fatalf("expect reads to go straight to the getter, got %q, %v after %d loads", v, err, loads)
+	}
+	if s := g.Stats(); s.CacheErrors != 2 || s.BypassTrips != 1 || s.Bypassed != 1 {
+		t.Fatalf("unexpected stats %+v", s)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	g.Get("d")
+	if len(alerts) != 2 || alerts[1] {
+		t.Fatalf("expect the bypass to end after the cooldown, got alerts %v", alerts)
+	}
+}
+
+func TestBypassGetMulti(t *testing.T) {
+	src := &batchDB{}
+	g := NewGroup("bypass-multi", 2<<10, src, WithBypassOnErrors(1, time.Minute, time.Minute, nil))
+	g.Set("Tom", []byte("stale"))
+	g.cacheFailed(errors.New("cache is broken"))
+
+	views, err := g.GetMulti([]string{"Tom", "Sam", "nobody"})
+	if err != nil || len(views) != 2 || views["Tom"].String() != "630" || views["Sam"].String() != "567" {
+		t.Fatalf("expect values straight from the getter, got %v (%v)", views, err)
+	}
+	if src.calls != 1 {
+		t.Fatalf("expect one batched load, got %d", src.calls)
+	}
+	if _, ok := g.mainCache.get("Sam"); ok {
+		t.Fatal("expect nothing to be cached while bypassing")
+	}
+
+	g.Set("Tom", []byte("new"))
+	if _, ok := g.mainCache.get("Tom"); ok {
+		t.Fatal("expect Set to drop the old copy instead of caching while bypassing")
+	}
+	if _, err := g.Increment("n", 1); !errors.Is(err, ErrCacheBypassed) {
+		t.Fatalf("expect ErrCacheBypassed, got %v", err)
+	}
+}
+
+func TestPublishExpvar(t *testing.T) {
+	g := NewGroup("expvar", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.Get("k")
+	g.Get("k")
+
+	PublishExpvar()
+	PublishExpvar()
+	var vars map[string]map[string]int64
+	if err := json.Unmarshal([]byte(expvar.Get("geecache").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if s := vars["expvar"]; s["gets"] != 2 || s["hits"] != 1 || s["loads_deduped"] != 1 {
+		t.Fatalf("unexpected counters %v", s)
+	}
+}
+
+func TestDestroyGroup(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) { return []byte(key), nil })
+	g := NewGroup("destroy", 2<<10, getter)
+	g.Get("k")
+	if !slices.Contains(ListGroups(), "destroy") {
+		t.Fatalf("expect the group to be listed, got %v", ListGroups())
+	}
+
+	if err := DestroyGroup("destroy"); err != nil {
+		t.Fatal(err)
+	}
+	if GetGroup("destroy") != nil || slices.Contains(ListGroups(), "destroy") {
+		t.Fatal("expect the group to be unregistered")
+	}
+	if s := g.Stats(); s.Items != 0 || s.Bytes != 0 {
+		t.Fatalf("expect the group to be purged, got %+v", s)
+	}
+	if err := DestroyGroup("destroy"); err != nil {
+		t.Fatalf("expect destroying an unknown group to be a no-op, got %v", err)
+	}
+	g = NewGroup("destroy", 2<<10, getter, WithJanitor(time.Hour))
+	if g != GetGroup("destroy") {
+		t.Fatal("expect the name to be reusable")
+	}
+
+	// 同名替换时旧Group按DestroyGroup的方式清理
+	NewGroup("destroy", 2<<10, getter)
+	if !g.bg.closed() {
+		t.Fatal("expect the replaced group's background loops to stop")
+	}
+	origins.mu.Lock()
+	_, ok := origins.groups[g]
+	origins.mu.Unlock()
+	if ok {
+		t.Fatal("expect the replaced group to give up its origin budget share")
+	}
+}
+
+func TestTypedGroup(t *testing.T) {
+	type user struct {
+		Name string
+		Age  int
+	}
+	loads := 0
+	users := NewTypedGroup(NewGroup("typed", 2<<10, TypedGetterFunc(JSONCodec[user]{},
+		func(_ context.Context, key string) (user, error) {
+			loads++
+			return user{Name: key, Age: 30}, nil
+		})), JSONCodec[user]{})
+
+	for i := 0; i < 2; i++ {
+		if u, err := users.Get(context.Background(), "tom"); err != nil || u != (user{"tom", 30}) {
+			t.Fatalf("expect a decoded user, got %+v, %v", u, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("expect the encoded value to be cached, got %d loads", loads)
+	}
+	users.Set("amy", user{"amy", 25})
+	if u, _ := users.Get(context.Background(), "amy"); u.Age != 25 || loads != 1 {
+		t.Fatalf("expect the set value, got %+v after %d loads", u, loads)
+	}
+
+	for _, codec := range []Codec[user]{JSONCodec[user]{}, GobCodec[user]{}} {
+		b, err := codec.Marshal(user{"x", 1})
+		if u, uerr := codec.Unmarshal(b); err != nil || uerr != nil || u != (user{"x", 1}) {
+			t.Fatalf("%T: expect a round trip, got %+v, %v, %v", codec, u, err, uerr)
+		}
+	}
+	pc := ProtoCodec[*pb.Response]{}
+	b, _ := pc.Marshal(&pb.Response{Value: []byte("v")})
+	if res, err := pc.Unmarshal(b); err != nil || string(res.Value) != "v" {
+		t.Fatalf("expect a proto round trip, got %v, %v", res, err)
+	}
+}
+
+func TestGetInto(t *testing.T) {
+	g := NewGroup("sink", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		if key == "proto" {
+			return ProtoCodec[*pb.Response]{}.Marshal(&pb.Response{Value: []byte("v")})
+		}
+		return []byte(key), nil
+	}))
+	ctx := context.Background()
+
+	b := make([]byte, 0, 8)
+	if err := g.GetInto(ctx, "bytes", ByteSink(&b)); err != nil || string(b) != "bytes" {
+		t.Fatalf("expect the bytes, got %q, %v", b, err)
+	}
+	b[0] = 'X'
+	if v, _ := g.Get("bytes"); v.String() != "bytes" {
+		t.Fatalf("expect ByteSink to copy, cache now holds %q", v)
+	}
+	var s string
+	if err := g.GetInto(ctx, "str", StringSink(&s)); err != nil || s != "str" {
+		t.Fatalf("expect the string, got %q, %v", s, err)
+	}
+	res := &pb.Response{Value: []byte("stale")}
+	if err := g.GetInto(ctx, "proto", ProtoSink(res)); err != nil || string(res.Value) != "v" {
+		t.Fatalf("expect the decoded message, got %v, %v", res, err)
+	}
+}
+
+func TestWarm(t *testing.T) {
+	var loads, running, peak atomic.Int64
+	g := NewGroup("warm", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads.Add(1)
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+		time.Sleep(5 * time.Millisecond)
+		if key == "bad" {
+			return nil, errors.New("boom")
+		}
+		return []byte(key), nil
+	}))
+
+	keys := []string{"a", "b", "c", "a", "d", "bad", "b"}
+	err := g.Warm(context.Background(), keys, 2)
+	if err == nil || !strings.Contains(err.Error(), "warming bad") {
+		t.Fatalf("expect the failed key to be reported, got %v", err)
+	}
+	if loads.Load() != 5 || peak.Load() > 2 {
+		t.Fatalf("expect 5 deduplicated loads at most 2 at a time, got %d loads, peak %d", loads.Load(), peak.Load())
+	}
+	for _, key := range []string{"a", "b", "c", "d"} {
+		if _, ok := g.lookupCache(key); !ok {
+			t.Fatalf("expect %s to be cached", key)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.Warm(ctx, []string{"e"}, 1); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expect a canceled warm-up to fail, got %v", err)
+	}
+}
+
+func TestWriteThrough(t *testing.T) {
+	store := map[string]string{"k": "old"}
+	var storeMu sync.Mutex
+	g := NewGroup("write-through", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		storeMu.Lock()
+		defer storeMu.Unlock()
+		return []byte(store[key]), nil
+	}), WithSetter(SetterFunc(func(ctx context.Context, key string, value []byte) error {
+		switch string(value) {
+		case "bad":
+			return errors.New("rejected")
+		case "hang":
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		storeMu.Lock()
+		defer storeMu.Unlock()
+		store[key] = string(value)
+		return nil
+	})))
+
+	g.Get("k")
+	if err := g.Set("k", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := g.Get("k"); v.String() != "new" || store["k"] != "new" {
+		t.Fatalf("expect the store and the cache to be updated, got %q and %q", store["k"], v)
+	}
+	if err := g.Set("k", []byte("bad")); err == nil {
+		t.Fatal("expect the store error to be returned")
+	}
+	if v, _ := g.Get("k"); v.String() != "new" {
+		t.Fatalf("expect the cache to be unchanged after a failed write, got %q", v)
+	}
+	if c := g.Config(); c.Setter != "geecache.SetterFunc" {
+		t.Fatalf("expect the setter to be reported, got %q", c.Setter)
+	}
+
+	// 挂起的数据源写入受ctx约束，不会一直占用写锁
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := g.SetWithTTLContext(ctx, "k", []byte("hang"), 0); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expect the write to end with ctx, got %v", err)
+	}
+	if err := g.Set("k", []byte("next")); err != nil {
+		t.Fatalf("expect the key to be writable again, got %v", err)
+	}
+}
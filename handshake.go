@@ -0,0 +1,148 @@
+package geecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// protocolVersion is the peer protocol spoken by this build. Peers that
+// report a different version may not understand each other's requests.
+const protocolVersion = 1
+
+// defaultClockSkewThreshold is the clock difference above which
+// Handshake warns; TTLs and leases silently misbehave beyond it.
+const defaultClockSkewThreshold = time.Second
+
+// WithClockSkewThreshold sets the clock difference Handshake warns about.
+func WithClockSkewThreshold(d time.Duration) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if d > 0 {
+			p.skewThreshold = d
+		}
+	}
+}
+
+// hello is the body of GET <basePath>?op=hello.
+type hello struct {
+	Version int      `json:"version"`
+	Groups  []string `json:"groups"`
+	Time    int64    `json:"time"` // UnixNano when the response was built
+}
+
+// PeerHandshake is what Handshake learned about one peer.
+type PeerHandshake struct {
+	Peer    string
+	Version int
+	Groups  []string
+	// Skew is the peer's clock minus ours, estimated at the midpoint of
+	// the round trip, so it is only accurate to about RTT/2.
+	Skew time.Duration
+	RTT  time.Duration
+	Err  error // set if the peer could not be reached
+}
+
+// Handshake exchanges protocol version, group list and wall-clock time
+// with every peer. Call it after Set when joining a cluster.
+//
+// It logs a warning for each peer whose version differs, whose groups
+// differ from ours, or whose clock is off by more than the skew
+// threshold, and returns the per-peer results. The error joins those
+// problems and any unreachable peers; it is advisory and the pool keeps
+// working either way.
+func (p *HTTPPool) Handshake(ctx context.Context) ([]PeerHandshake, error) {
+	names := p.peerNames()
+	sort.Strings(names)
+	groups := p.registry.ListGroups()
+
+	var (
+		results []PeerHandshake
+		errs    []error
+	)
+	for _, peer := range names {
+		h := p.getter(peer)
+		if h == nil {
+			continue
+		}
+		res := h.hello(ctx)
+		res.Peer = peer
+		results = append(results, res)
+
+		var problem error
+		switch {
+		case res.Err != nil:
+			problem = fmt.Errorf("handshake with %s: %w", peer, res.Err)
+		case res.Version != protocolVersion:
+			problem = fmt.Errorf("peer %s speaks protocol %d, we speak %d", peer, res.Version, protocolVersion)
+		case res.Skew > p.skewThreshold || -res.Skew > p.skewThreshold:
+			problem = fmt.Errorf("clock of peer %s is off by %v (rtt %v)", peer, res.Skew, res.RTT)
+		case !equalStrings(res.Groups, groups):
+			problem = fmt.Errorf("peer %s has groups %v, we have %v", peer, res.Groups, groups)
+		}
+		if problem != nil {
+			p.logger().Warn("handshake problem", "self", p.self, "problem", problem)
+			errs = append(errs, problem)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// serveHello answers a peer's handshake.
+func (p *HTTPPool) serveHello(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hello{
+		Version: protocolVersion,
+		Groups:  p.registry.ListGroups(),
+		Time:    time.Now().UnixNano(),
+	})
+}
+
+// hello performs the handshake request against the peer.
+func (h *httpGetter) hello(ctx context.Context) PeerHandshake {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.baseURL+"?op=hello", nil)
+	if err != nil {
+		return PeerHandshake{Err: err}
+	}
+	h.sign(req)
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	start := time.Now()
+	res, err := client.Do(req)
+	if err != nil {
+		return PeerHandshake{Err: err}
+	}
+	defer res.Body.Close()
+	end := time.Now()
+	if res.StatusCode != http.StatusOK {
+		return PeerHandshake{Err: serverError(res)}
+	}
+	var body hello
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return PeerHandshake{Err: fmt.Errorf("decoding handshake: %w", err)}
+	}
+	rtt := end.Sub(start)
+	return PeerHandshake{
+		Version: body.Version,
+		Groups:  body.Groups,
+		Skew:    time.Unix(0, body.Time).Sub(start.Add(rtt / 2)),
+		RTT:     rtt,
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package geecache
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// defaultHealthPath is served relative to the pool's base path.
+const defaultHealthPath = "health"
+
+// WithHealthPath serves the health check at path instead of
+// <basePath>health. A path outside the base path must also be routed to
+// the pool, e.g. mux.Handle("/healthz", pool).
+func WithHealthPath(path string) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.healthPath = path
+	}
+}
+
+// Health is the body of the health check response.
+type Health struct {
+	Status string `json:"status"` // "ok", or "draining" with a 503
+	Self   string `json:"self"`
+	Peers  int    `json:"peers"`  // peers known besides self
+	Groups int    `json:"groups"` // registered groups
+	Bytes  int64  `json:"bytes"`  // main and hot cache bytes of all groups
+	Items  int64  `json:"items"`  // main and hot cache entries of all groups
+}
+
+// Health reports the node status without issuing cache reads.
+func (p *HTTPPool) Health() Health {
+	h := Health{Status: "ok", Self: p.self, Peers: len(p.peerNames())}
+	if p.draining.Load() {
+		h.Status = "draining"
+	}
+	for _, g := range p.registry.snapshot() {
+		h.Groups++
+		h.Bytes += g.mainCache.bytes() + g.hotCache.bytes()
+		h.Items += g.mainCache.items() + g.hotCache.items()
+	}
+	return h
+}
+
+// serveHealth answers load balancer and Kubernetes probes.
+func (p *HTTPPool) serveHealth(w http.ResponseWriter) {
+	h := p.Health()
+	w.Header().Set("Content-Type", "application/json")
+	if h.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(h)
+}
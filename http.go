@@ -1,128 +1,720 @@
 package geecache
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"github/lhh-gh/geecache/consistenthash"
+	pb "github/lhh-gh/geecache/geecachepb"
+	"io"
 	"io/ioutil"
-	"log"
+	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	defaultBasePath = "/_geecache/"
 	defaultReplicas = 50
+	// unixScheme prefixes peers reached over a Unix domain socket,
+	// e.g. "unix:///var/run/geecache.sock".
+	unixScheme = "unix://"
 )
 
 // HTTPPool implements PeerPicker for a pool of HTTP peers.
 type HTTPPool struct {
 	// this peer's base URL, e.g. "https://example.net:8000"
-	self        string
-	basePath    string
-	mu          sync.Mutex // guards peers and httpGetters
-	peers       *consistenthash.Map
-	httpGetters map[string]*httpGetter // keyed by e.g. "http://10.0.0.2:8008"
+	self     string
+	basePath string
+	// membership snapshot read by every routing decision; replaced, never
+	// modified, by Set and removePeer while holding mu
+	state atomic.Pointer[peerRing]
+	mu    sync.Mutex // serializes membership changes; guards ringStreaks and server
+	// transport used to reach non-unix peers; nil means http.DefaultTransport
+	transport http.RoundTripper
+	// client used to reach non-unix peers, see WithHTTPClient
+	client *http.Client
+	// serializer the pool's getters ask their peers to encode responses with
+	serializer Serializer
+
+	drainGrace time.Duration
+	draining   atomic.Bool  // set by Drain once the grace period is over
+	serving    atomic.Int64 // peer requests currently being served
+
+	ringStreaks     map[string]int // consecutive disagreeing requests per peer, guarded by mu
+	ringMismatches  atomic.Int64
+	splitThreshold  int
+	onSplitBrain    SplitBrainFunc
+	strictOwnership bool
+
+	maxInflight int64 // 0 means unlimited
+	retryAfter  time.Duration
+	inflight    atomic.Int64
+	shed        atomic.Int64
+
+	audit         AuditSink
+	skewThreshold time.Duration
+	healthPath    string
+	secret        []byte        // signs and verifies peer requests; nil disables auth
+	compressMin   int           // smallest gzipped response body; 0 disables compression
+	failoverAfter time.Duration // see WithOwnerFailover; 0 disables failover
+	cacheStatus   bool          // see WithCacheStatus
+	tracer        Tracer        // see WithTracePropagation
+	log           Logger        // see WithPoolLogger; nil means slog.Default()
+	registry      *Registry     // groups the pool serves, see WithRegistry
+
+	deregister  func() error // see WithDeregister
+	handoffKeys int          // see WithHandoff
+	server      *http.Server // started by Serve, guarded by mu
+}
+
+// HTTPPoolOption configures an HTTPPool.
+type HTTPPoolOption func(*HTTPPool)
+
+// WithBasePath serves the pool under path instead of /_geecache/, e.g. to
+// mount it next to application routes. Every peer must use the same base
+// path, since the pool's clients address peers with it.
+func WithBasePath(path string) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		p.basePath = path
+	}
+}
+
+// WithHTTPClient makes the pool reach its non-unix peers with client, e.g.
+// to size its connection pool for a high fan-out, route through a proxy or
+// wrap the transport for tracing. A transport set by WithTransport,
+// WithTLSConfig or WithHTTP3 is used when client.Transport is nil.
+// Timeouts of client apply on top of the group's WithPeerTimeout.
+func WithHTTPClient(client *http.Client) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.client = client
+	}
+}
+
+// WithTransport makes the pool reach its non-unix peers through rt.
+func WithTransport(rt http.RoundTripper) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.transport = rt
+	}
 }
 
 // NewHTTPPool initializes an HTTP pool of peers.
-func NewHTTPPool(self string) *HTTPPool {
-	return &HTTPPool{
-		self:     self,
-		basePath: defaultBasePath,
+func NewHTTPPool(self string, opts ...HTTPPoolOption) *HTTPPool {
+	p := &HTTPPool{
+		self:       self,
+		basePath:   defaultBasePath,
+		drainGrace: defaultDrainGrace,
+		serializer: ProtoSerializer,
+		registry:   defaultRegistry,
+
+		splitThreshold: defaultSplitBrainThreshold,
+		skewThreshold:  defaultClockSkewThreshold,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	if p.healthPath == "" {
+		p.healthPath = p.basePath + defaultHealthPath
 	}
+	return p
 }
 
-// Log info with server name
+// Log logs an Info message through the pool's logger (see WithPoolLogger),
+// tagged with the pool's address.
 func (p *HTTPPool) Log(format string, v ...interface{}) {
-	log.Printf("[Server %s] %s", p.self, fmt.Sprintf(format, v...))
+	p.logger().Info(fmt.Sprintf(format, v...), "self", p.self)
+}
+
+// BasePath returns the path prefix the pool serves.
+func (p *HTTPPool) BasePath() string {
+	return p.basePath
+}
+
+// Handler returns a handler for mounting the pool into a larger server. It
+// serves the pool's paths and answers 404 for anything else, so it can be
+// installed as a catch-all without taking over application routes.
+func (p *HTTPPool) Handler() http.Handler {
+	return p
+}
+
+// Register mounts the pool on mux at its base path, and at its health path
+// when that lies outside the base path.
+func (p *HTTPPool) Register(mux *http.ServeMux) {
+	mux.Handle(p.basePath, p)
+	if !strings.HasPrefix(p.healthPath, p.basePath) {
+		mux.Handle(p.healthPath, p)
+	}
 }
 
 // ServeHTTP handle all http requests
 func (p *HTTPPool) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.cacheStatus {
+		w.Header().Set(NodeHeader, p.self)
+	}
+	if r.URL.Path == p.healthPath && r.Method == http.MethodGet {
+		p.serveHealth(w)
+		return
+	}
 	if !strings.HasPrefix(r.URL.Path, p.basePath) {
-		panic("HTTPPool serving unexpected path: " + r.URL.Path)
+		writeError(w, "not found: "+r.URL.Path, http.StatusNotFound)
+		return
 	}
-	p.Log("%s %s", r.Method, r.URL.Path)
+	p.logger().Debug("serving request", "self", p.self, "method", r.Method, "path", r.URL.Path)
+	w, audited := p.auditRequest(w, r)
+	defer audited()
+	if !p.authorize(w, r) {
+		return
+	}
+	if r.URL.Path == p.basePath {
+		p.serveMembership(w, r)
+		return
+	}
+	release, ok := p.admit(w)
+	if !ok {
+		return
+	}
+	defer release()
+	p.serving.Add(1)
+	defer p.serving.Add(-1)
+	if p.draining.Load() {
+		writeError(w, "draining", http.StatusServiceUnavailable)
+		return
+	}
+	agree := p.checkRing(r)
+	r = r.WithContext(WithRequestInfo(r.Context(), requestInfoFromHeaders(r.Header)))
 	// /<basepath>/<groupname>/<key> required
 	parts := strings.SplitN(r.URL.Path[len(p.basePath):], "/", 2)
 	if len(parts) != 2 {
-		http.Error(w, "bad request", http.StatusBadRequest)
+		writeError(w, "bad request", http.StatusBadRequest)
 		return
 	}
 
 	groupName := parts[0]
 	key := parts[1]
+	if p.tracer != nil {
+		ctx, end := p.tracer.Start(p.tracer.Extract(r.Context(), r.Header), spanServe, groupName, key)
+		defer end(nil)
+		r = r.WithContext(ctx)
+	}
 
-	group := GetGroup(groupName)
+	group := p.registry.GetGroup(groupName)
 	if group == nil {
-		http.Error(w, "no such group: "+groupName, http.StatusNotFound)
+		writeError(w, "no such group: "+groupName, http.StatusNotFound)
 		return
 	}
 
-	view, err := group.Get(key)
+	switch r.Method {
+	case http.MethodGet:
+		var (
+			view ByteView
+			hit  bool
+			err  error
+		)
+		if ms := r.URL.Query().Get("maxstale"); ms != "" {
+			maxStale, perr := time.ParseDuration(ms)
+			if perr != nil {
+				writeError(w, "bad maxstale: "+ms, http.StatusBadRequest)
+				return
+			}
+			view, hit, err = group.getWithMaxStale(r.Context(), key, maxStale)
+		} else {
+			view, hit, err = group.get(r.Context(), key)
+		}
+		if err != nil {
+			writeLoadError(w, err)
+			return
+		}
+		p.setCacheStatus(w, CacheStatus(view, hit))
+
+		sz := serializerFor(r.Header.Get("Accept"))
+		body, err := sz.Marshal(&pb.Response{Value: view.b})
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", sz.ContentType())
+		p.writeBody(w, r, body)
+	case http.MethodPost:
+		if r.URL.Query().Get("op") == "getmulti" {
+			p.serveGetMulti(w, r, group)
+			return
+		}
+		if !agree && p.strictOwnership {
+			writeError(w, "peer ring disagrees with "+p.self, http.StatusMisdirectedRequest)
+			return
+		}
+		p.serveUpdate(w, r, group, key)
+	case http.MethodDelete:
+		// validate replay before deleting, so a bad request changes nothing
+		var delay time.Duration
+		if replay := r.URL.Query().Get("replay"); replay != "" {
+			var err error
+			if delay, err = time.ParseDuration(replay); err == nil {
+				err = validReplayDelay(delay)
+			}
+			if err != nil {
+				writeError(w, "bad replay: "+replay, http.StatusBadRequest)
+				return
+			}
+		}
+		group.removeLocally(key)
+		if delay > 0 {
+			group.scheduleReplay(key, delay)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// batchRequest and batchResponse are the JSON bodies of a getmulti request.
+type batchRequest struct {
+	Keys []string `json:"keys"`
+}
+
+type batchResponse struct {
+	Values map[string][]byte `json:"values"`
+	Errors map[string]string `json:"errors,omitempty"` // per-key failures
+}
+
+// serveGetMulti answers a batched get for keys this node owns.
+func (p *HTTPPool) serveGetMulti(w http.ResponseWriter, r *http.Request, group *Group) {
+	var req batchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "bad batch request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	views, failed := group.getMultiLocally(r.Context(), req.Keys)
+	res := batchResponse{Values: make(map[string][]byte, len(views))}
+	for k, v := range views {
+		res.Values[k] = v.b
+	}
+	if len(failed) > 0 {
+		res.Errors = make(map[string]string, len(failed))
+		for k, err := range failed {
+			res.Errors[k] = err.Error()
+		}
+	}
+	body, err := json.Marshal(res)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	w.Header().Set("Content-Type", "application/json")
+	p.writeBody(w, r, body)
+}
 
-	w.Header().Set("Content-Type", "application/octet-stream")
-	w.Write(view.ByteSlice())
+// serveUpdate handles owner-arbitrated mutations, selected by the "op" query parameter.
+// The receiving node applies them locally without forwarding again.
+func (p *HTTPPool) serveUpdate(w http.ResponseWriter, r *http.Request, group *Group, key string) {
+	q := r.URL.Query()
+	switch op := q.Get("op"); op {
+	case "incr":
+		delta, err := strconv.ParseInt(q.Get("delta"), 10, 64)
+		if err != nil {
+			writeError(w, "bad delta: "+q.Get("delta"), http.StatusBadRequest)
+			return
+		}
+		n, err := group.incrementLocally(key, delta)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strconv.FormatInt(n, 10)))
+	case "append":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, err := group.appendLocally(key, data)
+		if errors.Is(err, ErrAppendTooLarge) {
+			writeError(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		} else if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(strconv.Itoa(n)))
+	case "cas":
+		// body is the expected value followed by the new value; n is len(expected)
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n, err := strconv.Atoi(q.Get("n"))
+		if err != nil || n < 0 || n > len(data) {
+			writeError(w, "bad n: "+q.Get("n"), http.StatusBadRequest)
+			return
+		}
+		swapped, err := group.casLocally(key, data[:n], data[n:])
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !swapped {
+			writeError(w, "value mismatch", http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	case "set":
+		// body is a handed-off value; expire, if given, is its expiry in unix nanoseconds
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var expire time.Time
+		if s := q.Get("expire"); s != "" {
+			ns, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				writeError(w, "bad expire: "+s, http.StatusBadRequest)
+				return
+			}
+			expire = time.Unix(0, ns)
+		}
+		if err := group.fillLocally(key, ByteView{b: data, e: expire, src: SourcePeer}); err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "unknown op: "+op, http.StatusBadRequest)
+	}
 }
 
-// Set updates the pool's list of peers.
+// Set updates the pool's list of peers. Peers that stay in the list keep
+// their getter, and with it their connections and failover state; the idle
+// socket connections of departed unix peers are closed.
 func (p *HTTPPool) Set(peers ...string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.peers = consistenthash.New(defaultReplicas, nil)
-	p.peers.Add(peers...)
-	p.httpGetters = make(map[string]*httpGetter, len(peers))
+	old := p.ring().getters
+	getters := make(map[string]*httpGetter, len(peers))
+	client := p.peerClient()
 	for _, peer := range peers {
-		p.httpGetters[peer] = &httpGetter{baseURL: peer + p.basePath}
+		if h, ok := old[peer]; ok {
+			getters[peer] = h
+			continue
+		}
+		h := newHTTPGetter(peer, p.basePath, client)
+		h.self, h.ring = p.self, p.RingHash
+		h.serializer = p.serializer
+		h.secret = p.secret
+		h.tracer = p.tracer
+		getters[peer] = h
+	}
+	p.state.Store(newPeerRing(getters))
+	for peer, h := range old {
+		if _, ok := getters[peer]; !ok {
+			h.closeIdle()
+		}
+	}
+	p.auditPeers(peers)
+}
+
+// peerClient returns the client for non-unix peers, nil meaning
+// http.DefaultClient.
+func (p *HTTPPool) peerClient() *http.Client {
+	switch {
+	case p.client == nil && p.transport == nil:
+		return nil
+	case p.client == nil:
+		return &http.Client{Transport: p.transport}
+	case p.client.Transport == nil && p.transport != nil:
+		c := *p.client
+		c.Transport = p.transport
+		return &c
+	default:
+		return p.client
 	}
 }
 
 // PickPeer picks a peer according to key
 func (p *HTTPPool) PickPeer(key string) (PeerGetter, bool) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-	if peer := p.peers.Get(key); peer != "" && peer != p.self {
-		p.Log("Pick peer %s", peer)
-		return p.httpGetters[peer], true
+	ring := p.ring()
+	if peer := p.owner(ring, key); peer != "" && peer != p.self {
+		p.logger().Debug("picked peer", "self", p.self, "peer", peer, "key", key)
+		return ring.getters[peer], true
 	}
 	return nil, false
 }
 
+// AllPeers returns the getters of all peers except self.
+func (p *HTTPPool) AllPeers() []PeerGetter {
+	getters := p.ring().getters
+	peers := make([]PeerGetter, 0, len(getters))
+	for peer, getter := range getters {
+		if peer != p.self {
+			peers = append(peers, getter)
+		}
+	}
+	return peers
+}
+
 var _ PeerPicker = (*HTTPPool)(nil)
 
+// ListenUnix listens on a Unix domain socket for sidecar deployments, where
+// TCP loopback and TLS are unnecessary overhead. A stale socket file left by a
+// previous process is removed first. Serve the pool on it with http.Serve.
+func ListenUnix(path string) (net.Listener, error) {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
+
 type httpGetter struct {
 	baseURL string
+	client  *http.Client // nil means http.DefaultClient
+	conns   connStats    // connection-level counters for this peer
+	// ownsClient is set when client serves only this peer (unix sockets)
+	ownsClient bool
+
+	// self and ring identify the sender to the peer; ring is nil for
+	// getters created outside a pool
+	self string
+	ring func() string
+	// serializer requested for Get responses; nil asks for raw bytes
+	serializer Serializer
+	// secret signs requests, see WithSharedSecret
+	secret []byte
+	// tracer propagates the trace context, see WithTracePropagation
+	tracer Tracer
+
+	failingSince atomic.Int64 // unix nanos of the first failure in a row, 0 if healthy
+	probing      atomic.Bool  // a failover probe is watching the peer
+}
+
+// newHTTPGetter returns a getter for peer. Peers with the unix:// scheme are
+// dialed over their socket; the host part of the request URL is ignored.
+// Other peers use client, or http.DefaultClient when it is nil.
+func newHTTPGetter(peer, basePath string, client *http.Client) *httpGetter {
+	path := strings.TrimPrefix(peer, unixScheme)
+	if path == peer {
+		return &httpGetter{baseURL: peer + basePath, client: client}
+	}
+	var d net.Dialer
+	return &httpGetter{
+		baseURL: "http://unix" + basePath,
+		client: &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return d.DialContext(ctx, "unix", path)
+			},
+		}},
+		ownsClient: true,
+	}
 }
 
-func (h *httpGetter) Get(group string, key string) ([]byte, error) {
+// closeIdle closes the idle connections of a getter that left the pool.
+// Clients shared with other peers are left alone.
+func (h *httpGetter) closeIdle() {
+	if h.ownsClient {
+		h.client.CloseIdleConnections()
+	}
+}
+
+// do sends a request for group/key to the peer. query may be empty.
+func (h *httpGetter) do(ctx context.Context, method, group, key, query string, body io.Reader) (*http.Response, error) {
 	u := fmt.Sprintf(
 		"%v%v/%v",
 		h.baseURL,
 		url.QueryEscape(group),
 		url.QueryEscape(key),
 	)
-	res, err := http.Get(u)
+	if query != "" {
+		u += "?" + query
+	}
+	req, err := http.NewRequestWithContext(h.conns.trace(ctx), method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	if h.serializer != nil {
+		req.Header.Set("Accept", h.serializer.ContentType())
+	}
+	setRequestInfoHeaders(ctx, req.Header)
+	if h.tracer != nil {
+		h.tracer.Inject(ctx, req.Header)
+	}
+	if h.ring != nil {
+		req.Header.Set(peerHeader, h.self)
+		req.Header.Set(ringHeader, h.ring())
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	h.sign(req)
+	client := h.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	done := h.conns.begin()
+	res, err := client.Do(req)
+	h.observe(res, err)
+	if err != nil {
+		done()
+		return nil, err
+	}
+	res.Body = &trackedBody{ReadCloser: res.Body, done: done}
+	if err := decompress(res); err != nil {
+		return nil, fmt.Errorf("decompressing response body: %v", err)
+	}
+	return res, nil
+}
+
+func (h *httpGetter) Get(ctx context.Context, group string, key string) ([]byte, error) {
+	var query string
+	if maxStale, ok := maxStaleFromContext(ctx); ok {
+		query = url.Values{"maxstale": {maxStale.String()}}.Encode()
+	}
+	res, err := h.do(ctx, http.MethodGet, group, key, query, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("server returned: %v", res.Status)
+		return nil, serverError(res)
 	}
 
-	bytes, err := ioutil.ReadAll(res.Body)
+	body, err := ioutil.ReadAll(res.Body)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %v", err)
 	}
 
-	return bytes, nil
+	var out pb.Response
+	if err := serializerFor(res.Header.Get("Content-Type")).Unmarshal(body, &out); err != nil {
+		return nil, fmt.Errorf("decoding response body: %v", err)
+	}
+	return out.Value, nil
+}
+
+func (h *httpGetter) GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error) {
+	body, err := json.Marshal(batchRequest{Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.do(ctx, http.MethodPost, group, "", "op=getmulti", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, serverError(res)
+	}
+	var out batchResponse
+	if err := json.NewDecoder(res.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding response body: %v", err)
+	}
+	if len(out.Errors) > 0 {
+		failed := make(BatchError, len(out.Errors))
+		for k, msg := range out.Errors {
+			failed[k] = errors.New(msg)
+		}
+		return out.Values, failed
+	}
+	return out.Values, nil
+}
+
+func (h *httpGetter) Increment(ctx context.Context, group string, key string, delta int64) (int64, error) {
+	q := url.Values{"op": {"incr"}, "delta": {strconv.FormatInt(delta, 10)}}
+	res, err := h.do(ctx, http.MethodPost, group, key, q.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, serverError(res)
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return 0, fmt.Errorf("reading response body: %v", err)
+	}
+	return strconv.ParseInt(string(body), 10, 64)
+}
+
+func (h *httpGetter) Append(ctx context.Context, group string, key string, data []byte) (int, error) {
+	res, err := h.do(ctx, http.MethodPost, group, key, "op=append", bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		body, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			return 0, fmt.Errorf("reading response body: %v", err)
+		}
+		return strconv.Atoi(string(body))
+	case http.StatusRequestEntityTooLarge:
+		return 0, ErrAppendTooLarge
+	default:
+		return 0, serverError(res)
+	}
 }
 
-var _ PeerGetter = (*httpGetter)(nil)
+func (h *httpGetter) CAS(ctx context.Context, group string, key string, old, new []byte) (bool, error) {
+	body := make([]byte, 0, len(old)+len(new))
+	body = append(append(body, old...), new...)
+	q := url.Values{"op": {"cas"}, "n": {strconv.Itoa(len(old))}}
+	res, err := h.do(ctx, http.MethodPost, group, key, q.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusConflict:
+		return false, nil
+	default:
+		return false, serverError(res)
+	}
+}
+
+func (h *httpGetter) Remove(ctx context.Context, group string, key string) error {
+	return h.remove(ctx, group, key, "")
+}
+
+// RemoveWithReplay removes key now and has the peer remove it again after delay.
+func (h *httpGetter) RemoveWithReplay(ctx context.Context, group string, key string, delay time.Duration) error {
+	return h.remove(ctx, group, key, url.Values{"replay": {delay.String()}}.Encode())
+}
+
+func (h *httpGetter) remove(ctx context.Context, group, key, query string) error {
+	res, err := h.do(ctx, http.MethodDelete, group, key, query, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return serverError(res)
+	}
+	return nil
+}
+
+var (
+	_ PeerGetter    = (*httpGetter)(nil)
+	_ ReplayRemover = (*httpGetter)(nil)
+)
@@ -0,0 +1,49 @@
+//go:build quic
+
+package geecache
+
+import (
+	"crypto/tls"
+	"net/http"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// WithHTTP3 makes the pool fetch from peers over HTTP/3 (QUIC), which holds up
+// better than TCP on lossy cross-zone links. Peers must serve the pool with
+// ListenAndServeHTTP3 and be addressed with https:// URLs.
+//
+// The TLS session cache lets reconnects resume the TLS session, but 0-RTT is
+// off on both ends: early data can be replayed, and peer POSTs such as Set,
+// Increment and Remove are not idempotent. Only built with the "quic" build
+// tag, so the quic-go dependency stays optional.
+func WithHTTP3(tlsConfig *tls.Config) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		conf := tlsConfig.Clone()
+		if conf == nil {
+			conf = &tls.Config{}
+		}
+		if conf.ClientSessionCache == nil {
+			conf.ClientSessionCache = tls.NewLRUClientSessionCache(0)
+		}
+		p.transport = &http3.Transport{
+			TLSClientConfig: conf,
+			QUICConfig:      &quic.Config{},
+		}
+	}
+}
+
+// ListenAndServeHTTP3 serves handler (usually an HTTPPool) over HTTP/3 on the UDP address addr.
+func ListenAndServeHTTP3(addr, certFile, keyFile string, handler http.Handler) error {
+	return newHTTP3Server(addr, handler).ListenAndServeTLS(certFile, keyFile)
+}
+
+// newHTTP3Server returns the server ListenAndServeHTTP3 runs, without 0-RTT.
+func newHTTP3Server(addr string, handler http.Handler) *http3.Server {
+	return &http3.Server{
+		Addr:       addr,
+		Handler:    handler,
+		QUICConfig: &quic.Config{},
+	}
+}
@@ -0,0 +1,49 @@
+//go:build quic
+
+package geecache
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestHTTP3(t *testing.T) {
+	NewGroup("http3", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("0"), nil }))
+	dir := t.TempDir()
+	ca, caKey, caFile, _ := writeCert(t, dir, "ca", nil, nil)
+	_, _, certFile, keyFile := writeCert(t, dir, "node", ca, caKey)
+	conf, err := LoadMutualTLS(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newHTTP3Server("", NewHTTPPool("self"))
+	srv.TLSConfig = http3.ConfigureTLSConfig(conf)
+	go srv.Serve(conn)
+	defer srv.Close()
+	if srv.QUICConfig.Allow0RTT {
+		t.Fatal("expect the server to refuse 0-RTT")
+	}
+
+	url := "https://" + conn.LocalAddr().String()
+	p := NewHTTPPool("self", WithHTTP3(conf))
+	p.Set(url)
+	if p.transport.(*http3.Transport).QUICConfig.Allow0RTT {
+		t.Fatal("expect the client not to send 0-RTT")
+	}
+	peer := p.getter(url)
+	if b, err := peer.Get(context.Background(), "http3", "k"); err != nil || string(b) != "0" {
+		t.Fatalf("expect a fetch over HTTP/3, got %q, %v", b, err)
+	}
+	if n, err := peer.Increment(context.Background(), "http3", "ctr", 2); err != nil || n != 2 {
+		t.Fatalf("expect a POST over HTTP/3, got %d, %v", n, err)
+	}
+}
@@ -0,0 +1,120 @@
+package geecache
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// PeerConnStats holds connection-level counters of the transport to one peer.
+// They help tell network problems (port exhaustion, TLS handshake storms)
+// apart from application latency.
+type PeerConnStats struct {
+	Dials         int64 // connection attempts
+	DialErrors    int64 // failed connection attempts
+	TLSHandshakes int64 // TLS handshakes started
+	TLSErrors     int64 // failed TLS handshakes
+	ConnsReused   int64 // requests served on an idle pooled connection
+	ConnsNew      int64 // requests that needed a fresh connection
+	InFlight      int64 // requests sent whose response body is not yet closed
+	InFlightPeak  int64 // highest InFlight observed
+}
+
+// ReuseRatio returns the fraction of requests served on reused connections.
+func (s PeerConnStats) ReuseRatio() float64 {
+	total := s.ConnsReused + s.ConnsNew
+	if total == 0 {
+		return 0
+	}
+	return float64(s.ConnsReused) / float64(total)
+}
+
+// connStats is the live, atomically updated form of PeerConnStats.
+type connStats struct {
+	dials, dialErrors      atomic.Int64
+	tlsHandshakes, tlsErrs atomic.Int64
+	connsReused, connsNew  atomic.Int64
+	inFlight, inFlightPeak atomic.Int64
+}
+
+func (s *connStats) snapshot() PeerConnStats {
+	return PeerConnStats{
+		Dials:         s.dials.Load(),
+		DialErrors:    s.dialErrors.Load(),
+		TLSHandshakes: s.tlsHandshakes.Load(),
+		TLSErrors:     s.tlsErrs.Load(),
+		ConnsReused:   s.connsReused.Load(),
+		ConnsNew:      s.connsNew.Load(),
+		InFlight:      s.inFlight.Load(),
+		InFlightPeak:  s.inFlightPeak.Load(),
+	}
+}
+
+// trace attaches an httptrace.ClientTrace that feeds the counters.
+func (s *connStats) trace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			s.dials.Add(1)
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				s.dialErrors.Add(1)
+			}
+		},
+		TLSHandshakeStart: func() {
+			s.tlsHandshakes.Add(1)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err != nil {
+				s.tlsErrs.Add(1)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				s.connsReused.Add(1)
+			} else {
+				s.connsNew.Add(1)
+			}
+		},
+	})
+}
+
+// begin marks a request in flight; the returned func marks it done.
+func (s *connStats) begin() (done func()) {
+	n := s.inFlight.Add(1)
+	for {
+		peak := s.inFlightPeak.Load()
+		if n <= peak || s.inFlightPeak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	var once atomic.Bool
+	return func() {
+		if once.CompareAndSwap(false, true) {
+			s.inFlight.Add(-1)
+		}
+	}
+}
+
+// trackedBody ends the in-flight window when the response body is closed.
+type trackedBody struct {
+	io.ReadCloser
+	done func()
+}
+
+func (b *trackedBody) Close() error {
+	defer b.done()
+	return b.ReadCloser.Close()
+}
+
+// ConnStats returns connection-level counters for every peer.
+func (p *HTTPPool) ConnStats() map[string]PeerConnStats {
+	getters := p.ring().getters
+	stats := make(map[string]PeerConnStats, len(getters))
+	for peer, getter := range getters {
+		stats[peer] = getter.conns.snapshot()
+	}
+	return stats
+}
@@ -0,0 +1,1403 @@
+package geecache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPicker 总是把key路由到同一个远端节点，用于验证所属节点仲裁
+type testPicker struct {
+	peer PeerGetter
+}
+
+func (p *testPicker) PickPeer(key string) (PeerGetter, bool) {
+	return p.peer, true
+}
+
+func (p *testPicker) AllPeers() []PeerGetter {
+	return []PeerGetter{p.peer}
+}
+
+// testOwner 模拟key的所属节点：独立注册表中的Group，由自己的HTTPPool对外服务
+type testOwner struct {
+	group *Group
+	pool  *HTTPPool
+	url   string // 含基础路径，如 http://127.0.0.1:1234/_geecache/
+}
+
+// newTestOwner 在独立的注册表中创建名为name的Group并启动服务，测试结束时关闭
+// 与被测Group同名也互不影响，二者分属不同的注册表
+func newTestOwner(t *testing.T, name string, getter Getter, groupOpts []GroupOption, poolOpts ...HTTPPoolOption) *testOwner {
+	t.Helper()
+	reg := NewRegistry()
+	o := &testOwner{
+		group: reg.NewGroup(name, 2<<10, getter, groupOpts...),
+		pool:  NewHTTPPool("owner", append([]HTTPPoolOption{WithRegistry(reg)}, poolOpts...)...),
+	}
+	srv := httptest.NewServer(o.pool)
+	t.Cleanup(func() {
+		srv.Close()
+		reg.DestroyGroup(name)
+	})
+	o.url = srv.URL + defaultBasePath
+	return o
+}
+
+// peer 返回访问该节点的httpGetter
+func (o *testOwner) peer() *httpGetter {
+	return &httpGetter{baseURL: o.url}
+}
+
+func TestHTTPIncrement(t *testing.T) {
+	g := NewGroup("counters", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("0"), nil }))
+
+	o := newTestOwner(t, "counters", GetterFunc(
+		func(key string) ([]byte, error) { return []byte("0"), nil }), nil)
+	owner := o.group
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	if n, err := g.Increment("hits", 5); err != nil || n != 5 {
+		t.Fatalf("expect 5, got %d (%v)", n, err)
+	}
+	if n, err := g.Increment("hits", 3); err != nil || n != 8 {
+		t.Fatalf("expect 8, got %d (%v)", n, err)
+	}
+	if view, ok := owner.mainCache.get("hits"); !ok || view.String() != "8" {
+		t.Fatalf("expect owner to hold 8, got %q", view)
+	}
+	if _, ok := g.mainCache.get("hits"); ok {
+		t.Fatal("non-owner should not store the counter")
+	}
+	if view, err := g.Get("hits"); err != nil || view.String() != "8" {
+		t.Fatalf("expect Get to read 8 from owner, got %q (%v)", view, err)
+	}
+
+	owner.Set("text", []byte("abc"))
+	if _, err := g.Increment("text", 1); err == nil || !strings.Contains(err.Error(), "not an integer") {
+		t.Fatalf("expect the owner's error when incrementing a non-integer value, got %v", err)
+	}
+}
+
+// recordingTracer 记录开始的span，并以请求头传递发起请求的span名
+type recordingTracer struct {
+	mu        sync.Mutex
+	ops       []string
+	extracted []string
+}
+
+type spanKey struct{}
+
+func (r *recordingTracer) Start(ctx context.Context, op, group, key string) (context.Context, func(error)) {
+	r.mu.Lock()
+	r.ops = append(r.ops, op)
+	r.mu.Unlock()
+	return context.WithValue(ctx, spanKey{}, op), func(error) {}
+}
+
+func (r *recordingTracer) Inject(ctx context.Context, h http.Header) {
+	op, _ := ctx.Value(spanKey{}).(string)
+	h.Set("X-Test-Span", op)
+}
+
+func (r *recordingTracer) Extract(ctx context.Context, h http.Header) context.Context {
+	r.mu.Lock()
+	r.extracted = append(r.extracted, h.Get("X-Test-Span"))
+	r.mu.Unlock()
+	return ctx
+}
+
+func TestTracing(t *testing.T) {
+	tr := &recordingTracer{}
+	g := NewGroup("tracing", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return nil, errors.New("not the owner") }),
+		WithTracer(tr))
+	o := newTestOwner(t, "tracing", GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }),
+		[]GroupOption{WithTracer(tr)}, WithTracePropagation(tr))
+	g.RegisterPeers(&testPicker{peer: &httpGetter{baseURL: o.url, tracer: tr}})
+
+	if v, err := g.Get("k"); err != nil || v.String() != "k" {
+		t.Fatalf("expect k from the owner, got %q, %v", v, err)
+	}
+	expect := []string{spanGet, spanLoad, spanPeer, spanServe, spanGet, spanLoad, spanGetter}
+	if !reflect.DeepEqual(tr.ops, expect) {
+		t.Fatalf("expect spans %v, got %v", expect, tr.ops)
+	}
+	if !reflect.DeepEqual(tr.extracted, []string{spanPeer}) {
+		t.Fatalf("expect the owner to continue the peer span, got %v", tr.extracted)
+	}
+}
+
+func TestHTTPRemove(t *testing.T) {
+	g := NewGroup("remove", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("origin"), nil }))
+
+	o := newTestOwner(t, "remove", GetterFunc(
+		func(key string) ([]byte, error) { return []byte("origin"), nil }), nil)
+	owner := o.group
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	owner.Set("k", []byte("v"))
+	g.Set("k", []byte("stale")) // 非所属节点上的残留副本
+	if err := g.Remove("k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := owner.mainCache.get("k"); ok {
+		t.Fatal("expect owner copy to be removed")
+	}
+	if _, ok := g.mainCache.get("k"); ok {
+		t.Fatal("expect local copy to be removed")
+	}
+}
+
+func TestHTTPWriteThrough(t *testing.T) {
+	var store sync.Map
+	store.Store("k", "old")
+	load := GetterFunc(func(key string) ([]byte, error) {
+		v, _ := store.Load(key)
+		return []byte(v.(string)), nil
+	})
+	g := NewGroup("write-through-peers", 2<<10, load,
+		WithSetter(SetterFunc(func(_ context.Context, key string, value []byte) error {
+			store.Store(key, string(value))
+			return nil
+		})))
+	o := newTestOwner(t, "write-through-peers", load, nil)
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	if v, _ := o.group.Get("k"); v.String() != "old" {
+		t.Fatalf("expect the owner to cache old, got %q", v)
+	}
+	if err := g.Set("k", []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := o.group.mainCache.get("k"); ok {
+		t.Fatal("expect the write to invalidate the owner's copy")
+	}
+	if v, _ := o.group.Get("k"); v.String() != "new" {
+		t.Fatalf("expect the owner to reload the written value, got %q", v)
+	}
+
+	// 挂起的节点不会让写入一直持有写锁
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer srv.Close()
+	defer close(unblock)
+	g.peerTimeout = 20 * time.Millisecond
+	g.peers = &testPicker{peer: &httpGetter{baseURL: srv.URL + defaultBasePath}}
+	start := time.Now()
+	if err := g.Set("k", []byte("newer")); !errors.Is(err, ErrPeerTimeout) {
+		t.Fatalf("expect the invalidation to time out, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expect the hung peer to be abandoned after the timeout, took %v", d)
+	}
+}
+
+func TestDeleteWithReplay(t *testing.T) {
+	g := NewGroup("replay", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("origin"), nil }))
+
+	o := newTestOwner(t, "replay", GetterFunc(
+		func(key string) ([]byte, error) { return []byte("origin"), nil }), nil)
+	owner := o.group
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	owner.Set("k", []byte("v"))
+	if err := g.DeleteWithReplay("k", 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	owner.Set("k", []byte("stale")) // 并发读在删除后写回旧值
+	g.Set("k", []byte("stale"))
+
+	time.Sleep(50 * time.Millisecond)
+	if _, ok := owner.mainCache.get("k"); ok {
+		t.Fatal("expect the owner to replay the delete")
+	}
+	if _, ok := g.mainCache.get("k"); ok {
+		t.Fatal("expect the local replay to remove the stale copy")
+	}
+
+	// 非法的replay在删除之前被拒绝
+	owner.Set("k", []byte("v"))
+	for _, replay := range []string{"soon", "-1s", "48h"} {
+		req, _ := http.NewRequest(http.MethodDelete, o.url+"replay/k?replay="+replay, nil)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expect 400 for replay=%s, got %v", replay, res.Status)
+		}
+	}
+	if _, ok := owner.mainCache.get("k"); !ok {
+		t.Fatal("expect a rejected delete to leave the value alone")
+	}
+	if err := g.DeleteWithReplay("k", 0); err == nil {
+		t.Fatal("expect a non-positive delay to be rejected")
+	}
+}
+
+func TestHTTPCAS(t *testing.T) {
+	g := NewGroup("cas", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("v1"), nil }))
+
+	o := newTestOwner(t, "cas", GetterFunc(
+		func(key string) ([]byte, error) { return []byte("v1"), nil }), nil)
+	owner := o.group
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	if ok, err := g.CAS("k", ByteView{}, []byte("v1")); err != nil || !ok {
+		t.Fatalf("expect create-if-absent to succeed, got %v (%v)", ok, err)
+	}
+	old, err := g.Get("k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := g.CAS("k", old, []byte("v2")); err != nil || !ok {
+		t.Fatalf("expect swap to succeed, got %v (%v)", ok, err)
+	}
+	if ok, err := g.CAS("k", old, []byte("v3")); err != nil || ok {
+		t.Fatalf("expect stale swap to fail, got %v (%v)", ok, err)
+	}
+	if view, ok := owner.mainCache.get("k"); !ok || view.String() != "v2" {
+		t.Fatalf("expect owner to hold v2, got %q", view)
+	}
+}
+
+func TestHotCache(t *testing.T) {
+	g := NewGroup("hot", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("local"), nil }))
+
+	o := newTestOwner(t, "hot", GetterFunc(
+		func(key string) ([]byte, error) { return []byte("remote"), nil }), nil)
+	owner := o.group
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	// 按概率写入，足够多次访问后应进入hotCache
+	for i := 0; i < 200; i++ {
+		if view, err := g.Get("k"); err != nil || view.String() != "remote" {
+			t.Fatalf("expect remote, got %q (%v)", view, err)
+		}
+	}
+	if _, ok := g.hotCache.get("k"); !ok {
+		t.Fatal("expect repeatedly fetched key to be in hot cache")
+	}
+	if _, ok := g.mainCache.get("k"); ok {
+		t.Fatal("remote key should not be stored in main cache")
+	}
+
+	if err := g.Remove("k"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := g.hotCache.get("k"); ok {
+		t.Fatal("expect Remove to clear the hot cache")
+	}
+	if _, ok := owner.mainCache.get("k"); ok {
+		t.Fatal("expect Remove to clear the owner")
+	}
+}
+
+func TestFallbackPeer(t *testing.T) {
+	NewGroup("fallback", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("v"), nil }))
+
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	// 关闭的端口模拟不可用的主传输
+	down := httptest.NewServer(nil)
+	down.Close()
+
+	peer := NewFallbackPeer(
+		"grpc", &httpGetter{baseURL: down.URL + defaultBasePath},
+		"http", &httpGetter{baseURL: srv.URL + defaultBasePath})
+	if v, err := peer.Get(context.Background(), "fallback", "k"); err != nil || string(v) != "v" {
+		t.Fatalf("expect fallback to serve v, got %q (%v)", v, err)
+	}
+	if served := peer.Served(); served["http"] != 1 || served["grpc"] != 0 {
+		t.Fatalf("unexpected served counts %v", served)
+	}
+}
+
+// countingTransport 统计经过的请求数
+type countingTransport struct {
+	n atomic.Int64
+}
+
+func (c *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	c.n.Add(1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestHTTPClient(t *testing.T) {
+	NewGroup("client", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	srv := httptest.NewServer(NewHTTPPool("owner"))
+	defer srv.Close()
+
+	rt := &countingTransport{}
+	p := NewHTTPPool("self", WithHTTPClient(&http.Client{Timeout: time.Second}), WithTransport(rt))
+	p.Set("self", srv.URL)
+	peer, ok := p.PickPeer("k")
+	for i := 0; !ok && i < 100; i++ {
+		peer, ok = p.PickPeer(strconv.Itoa(i))
+	}
+	if !ok {
+		t.Fatal("expect some key to be owned by the remote peer")
+	}
+	if _, err := peer.Get(context.Background(), "client", "k"); err != nil {
+		t.Fatal(err)
+	}
+	if n := rt.n.Load(); n != 1 {
+		t.Fatalf("expect the request to go through the injected transport, got %d", n)
+	}
+}
+
+func TestUnixSocketPeer(t *testing.T) {
+	NewGroup("uds", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("via-" + key), nil }))
+
+	path := filepath.Join(t.TempDir(), "geecache.sock")
+	l, err := ListenUnix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: NewHTTPPool(unixScheme + path)}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	peer := newHTTPGetter(unixScheme+path, defaultBasePath, nil)
+	if v, err := peer.Get(context.Background(), "uds", "sock"); err != nil || string(v) != "via-sock" {
+		t.Fatalf("expect via-sock, got %q (%v)", v, err)
+	}
+}
+
+func TestSetKeepsGetters(t *testing.T) {
+	pool := NewHTTPPool("self")
+	a, b := unixScheme+"/tmp/a.sock", unixScheme+"/tmp/b.sock"
+	pool.Set(a, b)
+	kept := pool.getter(a)
+	kept.failingSince.Store(1)
+
+	pool.Set(a, "http://c")
+	if pool.getter(a) != kept || kept.failingSince.Load() != 1 {
+		t.Fatal("expect an unchanged peer to keep its getter and failover state")
+	}
+	if pool.getter(b) != nil {
+		t.Fatal("expect the departed peer to be dropped")
+	}
+}
+
+func TestConnStats(t *testing.T) {
+	NewGroup("conns", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	pool := NewHTTPPool("self")
+	pool.Set(srv.URL)
+	peer := pool.getter(srv.URL)
+	for i := 0; i < 3; i++ {
+		if _, err := peer.Get(context.Background(), "conns", "k"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := pool.ConnStats()[srv.URL]
+	if s.ConnsNew+s.ConnsReused != 3 || s.ConnsReused == 0 || s.InFlight != 0 {
+		t.Fatalf("unexpected conn stats %+v", s)
+	}
+	if s.Dials != s.ConnsNew || s.DialErrors != 0 {
+		t.Fatalf("expect one dial per new connection, got %+v", s)
+	}
+}
+
+// batchDB 实现BatchGetter，记录批量回源次数
+type batchDB struct {
+	calls int
+}
+
+func (b *batchDB) Get(key string) ([]byte, error) {
+	return nil, fmt.Errorf("expect batched loads only")
+}
+
+func (b *batchDB) GetMulti(_ context.Context, keys []string) (map[string][]byte, error) {
+	b.calls++
+	values := make(map[string][]byte)
+	failed := make(BatchError)
+	for _, k := range keys {
+		if v, ok := db[k]; ok {
+			values[k] = []byte(v)
+		} else if strings.HasPrefix(k, "broken") {
+			failed[k] = fmt.Errorf("%s is broken", k)
+		}
+	}
+	if len(failed) > 0 {
+		return values, failed
+	}
+	return values, nil
+}
+
+func TestGetMulti(t *testing.T) {
+	local := &batchDB{}
+	g := NewGroup("multi", 2<<10, local)
+
+	remote := &batchDB{}
+	o := newTestOwner(t, "multi", remote, []GroupOption{WithNegativeCache(time.Minute)})
+	g.RegisterPeers(&splitPicker{
+		remote: map[string]bool{"Tom": true, "Jack": true, "nobody": true, "broken-remote": true},
+		peer:   o.peer(),
+	})
+
+	views, err := g.GetMulti([]string{"Tom", "Jack", "Sam", "nobody", "Tom", "broken-remote", "broken-local"})
+	if len(views) != 3 || views["Tom"].String() != "630" || views["Jack"].String() != "589" || views["Sam"].String() != "567" {
+		t.Fatalf("unexpected values %v", views)
+	}
+	if local.calls != 1 || remote.calls != 1 {
+		t.Fatalf("expect one batched load per node, got local=%d remote=%d", local.calls, remote.calls)
+	}
+	var be BatchError
+	if !errors.As(err, &be) || len(be) != 2 || be["broken-remote"] == nil || be["broken-local"] == nil {
+		t.Fatalf("expect per-key errors for broken keys, got %v", err)
+	}
+
+	// 已缓存的值与负缓存的不存在key都不再回源
+	if _, err := g.GetMulti([]string{"Tom", "Sam", "nobody"}); err != nil {
+		t.Fatal(err)
+	}
+	if local.calls != 1 || remote.calls != 1 {
+		t.Fatalf("expect no further loads, got local=%d remote=%d", local.calls, remote.calls)
+	}
+
+	// 本节点未配置WithNegativeCache，不存在的key每次都回源
+	for i := 0; i < 2; i++ {
+		if views, err := g.GetMulti([]string{"ghost"}); err != nil || len(views) != 0 {
+			t.Fatalf("expect ghost to be absent, got %v (%v)", views, err)
+		}
+	}
+	if local.calls != 3 {
+		t.Fatalf("expect not-found keys to be reloaded without a negative cache, got %d loads", local.calls)
+	}
+}
+
+// splitPicker 将remote中的key路由到peer，其余key由本节点负责
+type splitPicker struct {
+	remote map[string]bool
+	peer   PeerGetter
+}
+
+func (p *splitPicker) PickPeer(key string) (PeerGetter, bool) {
+	if p.remote[key] {
+		return p.peer, true
+	}
+	return nil, false
+}
+
+func (p *splitPicker) AllPeers() []PeerGetter {
+	return []PeerGetter{p.peer}
+}
+
+// hotRand 让每个远端结果都写入hotCache
+type hotRand struct{}
+
+func (hotRand) Intn(int) int       { return 0 }
+func (hotRand) Int63n(int64) int64 { return 0 }
+func (hotRand) Float64() float64   { return 0 }
+
+func TestRemoveDuringGetMulti(t *testing.T) {
+	var once sync.Once
+	started, unblock := make(chan struct{}), make(chan struct{})
+	o := newTestOwner(t, "removeduringbatch", GetterFunc(func(key string) ([]byte, error) {
+		once.Do(func() { close(started) })
+		<-unblock
+		return []byte("before delete"), nil
+	}), nil)
+	g := NewGroup("removeduringbatch", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return nil, errors.New("unused") }))
+	g.rand = hotRand{}
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	done := make(chan map[string]ByteView)
+	go func() {
+		views, _ := g.GetMulti([]string{"k"})
+		done <- views
+	}()
+	<-started
+	if err := g.Remove("k"); err != nil {
+		t.Fatal(err)
+	}
+	close(unblock)
+
+	if views := <-done; views["k"].String() != "before delete" {
+		t.Fatalf("expect the in-flight batch to return its value, got %v", views)
+	}
+	if _, ok := g.hotCache.get("k"); ok {
+		t.Fatal("expect a batch raced by Remove not to populate hotCache")
+	}
+}
+
+func TestDrain(t *testing.T) {
+	NewGroup("drain", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+
+	var a *HTTPPool
+	srvA := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.ServeHTTP(w, r)
+	}))
+	defer srvA.Close()
+	b := NewHTTPPool("http://b")
+	srvB := httptest.NewServer(b)
+	defer srvB.Close()
+
+	a = NewHTTPPool(srvA.URL, WithDrainGrace(10*time.Millisecond))
+	a.Set(srvA.URL, srvB.URL)
+	b.Set(srvA.URL, srvB.URL, "http://b")
+
+	if err := a.Drain(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(b.AllPeers()); n != 1 {
+		t.Fatalf("expect the drained node to leave b's ring, b still has %d peers", n)
+	}
+	for _, key := range []string{"k1", "k2", "k3", "k4"} {
+		if peer, ok := b.PickPeer(key); ok && peer.(*httpGetter).baseURL == srvA.URL+defaultBasePath {
+			t.Fatalf("key %s still routed to the drained node", key)
+		}
+	}
+
+	res, err := http.Get(srvA.URL + defaultBasePath + "drain/k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 after draining, got %v", res.Status)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	regA, regB := NewRegistry(), NewRegistry()
+	g := regA.NewGroup("shutdown", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	var loads atomic.Int64
+	successor := regB.NewGroup("shutdown", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { loads.Add(1); return []byte(key), nil }))
+
+	var handedOff atomic.Int64
+	b := NewHTTPPool("http://b", WithRegistry(regB))
+	srvB := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && r.URL.Query().Get("op") == "set" {
+			handedOff.Add(1)
+		}
+		b.ServeHTTP(w, r)
+	}))
+	defer srvB.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	self := "http://" + l.Addr().String()
+	deregistered := false
+	a := NewHTTPPool(self, WithRegistry(regA), WithDrainGrace(0), WithHandoff(2),
+		WithDeregister(func() error { deregistered = true; return nil }))
+	a.Set(self, srvB.URL)
+	b.Set(self, srvB.URL)
+	served := make(chan error, 1)
+	go func() { served <- a.Serve(l) }()
+
+	expire := time.Now().Add(time.Hour)
+	for _, key := range []string{"k1", "k2", "k3"} {
+		g.mainCache.add(key, ByteView{b: []byte("cached-" + key), e: expire})
+	}
+	if err := a.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if !deregistered {
+		t.Fatal("expect Shutdown to deregister the node")
+	}
+	if err := <-served; err != http.ErrServerClosed {
+		t.Fatalf("expect Serve to return ErrServerClosed, got %v", err)
+	}
+	if n := handedOff.Load(); n != 2 {
+		t.Fatalf("expect 2 hot entries handed off to b, got %d", n)
+	}
+	for _, key := range []string{"k2", "k3"} {
+		v, ok := successor.mainCache.get(key)
+		if !ok || v.String() != "cached-"+key || !v.e.Equal(expire) {
+			t.Fatalf("expect %s to arrive with its value and expiry, got %q, %v", key, v, v.e)
+		}
+	}
+	if loads.Load() != 0 {
+		t.Fatalf("expect the handoff not to load from the origin, got %d loads", loads.Load())
+	}
+}
+
+func TestLoadShedding(t *testing.T) {
+	started, unblock := make(chan struct{}), make(chan struct{})
+	NewGroup("shed", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) {
+			close(started)
+			<-unblock
+			return []byte(key), nil
+		}))
+	p := NewHTTPPool("self", WithMaxInflight(1, 1500*time.Millisecond))
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	done := make(chan int)
+	go func() {
+		res, err := http.Get(srv.URL + defaultBasePath + "shed/slow")
+		if err != nil {
+			done <- 0
+			return
+		}
+		res.Body.Close()
+		done <- res.StatusCode
+	}()
+	<-started
+
+	res, err := http.Get(srv.URL + defaultBasePath + "shed/other")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable || res.Header.Get("Retry-After") != "2" {
+		t.Fatalf("expect 503 with Retry-After 2, got %v %q", res.Status, res.Header.Get("Retry-After"))
+	}
+	if p.Shed() != 1 {
+		t.Fatalf("expect 1 shed request, got %d", p.Shed())
+	}
+
+	close(unblock)
+	if code := <-done; code != http.StatusOK {
+		t.Fatalf("expect the admitted request to succeed, got %d", code)
+	}
+}
+
+func TestAuditSink(t *testing.T) {
+	NewGroup("audit", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	var events []AuditEvent
+	p := NewHTTPPool("self", WithAuditSink(AuditFunc(func(e AuditEvent) { events = append(events, e) })))
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	p.Set("self")
+	http.Get(srv.URL + defaultBasePath + "audit/k") // 读请求不记录
+	req, _ := http.NewRequest(http.MethodDelete, srv.URL+defaultBasePath+"audit/k", nil)
+	req.Header.Set(peerHeader, "http://peer")
+	if res, err := http.DefaultClient.Do(req); err == nil {
+		res.Body.Close()
+	}
+	if res, err := http.Post(srv.URL+defaultBasePath+"audit/n?op=incr&delta=2", "", nil); err == nil {
+		res.Body.Close()
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expect 3 audit events, got %+v", events)
+	}
+	if e := events[0]; e.Op != "set-peers" || e.Actor != "local" || e.Detail != "self" {
+		t.Fatalf("unexpected peer list event %+v", e)
+	}
+	if e := events[1]; e.Op != "delete" || e.Actor != "http://peer" || e.Group != "audit" || e.Key != "k" || e.Status != http.StatusNoContent {
+		t.Fatalf("unexpected delete event %+v", e)
+	}
+	if e := events[2]; e.Op != "incr" || e.Key != "n" || e.Detail != "op=incr&delta=2" || e.Status != http.StatusOK {
+		t.Fatalf("unexpected incr event %+v", e)
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	b := httptest.NewServer(NewHTTPPool("http://b"))
+	defer b.Close()
+	skewed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(hello{Version: protocolVersion, Groups: ListGroups(), Time: time.Now().Add(time.Hour).UnixNano()})
+	}))
+	defer skewed.Close()
+
+	a := NewHTTPPool("self")
+	a.Set("self", b.URL, skewed.URL)
+	results, err := a.Handshake(context.Background())
+	if len(results) != 2 {
+		t.Fatalf("expect a result per peer, got %+v", results)
+	}
+	for _, res := range results {
+		switch res.Peer {
+		case b.URL:
+			if res.Err != nil || res.Version != protocolVersion || res.Skew > time.Second || res.Skew < -time.Second {
+				t.Fatalf("expect a clean handshake with b, got %+v", res)
+			}
+		case skewed.URL:
+			if res.Skew < 59*time.Minute {
+				t.Fatalf("expect about an hour of skew, got %v", res.Skew)
+			}
+		}
+	}
+	if err == nil || !strings.Contains(err.Error(), "clock of peer "+skewed.URL) {
+		t.Fatalf("expect the skewed peer to be reported, got %v", err)
+	}
+}
+
+func TestHealth(t *testing.T) {
+	g := NewGroup("health", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	g.Set("k", []byte("v"))
+	p := NewHTTPPool("self")
+	p.Set("self", "http://peer")
+	srv := httptest.NewServer(p)
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + defaultBasePath + "health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h Health
+	err = json.NewDecoder(res.Body).Decode(&h)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expect a 200 health response, got %v, %v", res.Status, err)
+	}
+	if h.Status != "ok" || h.Self != "self" || h.Peers != 1 || h.Groups < 1 || h.Items < 1 {
+		t.Fatalf("unexpected health %+v", h)
+	}
+
+	p.draining.Store(true)
+	res, err = http.Get(srv.URL + defaultBasePath + "health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expect 503 while draining, got %v", res.Status)
+	}
+}
+
+func TestCompression(t *testing.T) {
+	NewGroup("gzip", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(strings.Repeat(key, 100)), nil }))
+	srv := httptest.NewServer(NewHTTPPool("b", WithCompression(200)))
+	defer srv.Close()
+
+	for key, want := range map[string]string{"k": "", "big": "gzip"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+defaultBasePath+"gzip/"+key, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		res, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got := res.Header.Get("Content-Encoding"); got != want {
+			t.Fatalf("expect Content-Encoding %q for %s, got %q", want, key, got)
+		}
+	}
+
+	a := NewHTTPPool("a")
+	a.Set("a", srv.URL)
+	v, err := a.getter(srv.URL).Get(context.Background(), "gzip", "big")
+	if err != nil || string(v) != strings.Repeat("big", 100) {
+		t.Fatalf("expect the peer client to decompress, got %d bytes, %v", len(v), err)
+	}
+	vals, err := a.getter(srv.URL).GetMulti(context.Background(), "gzip", []string{"big", "k"})
+	if err != nil || len(vals) != 2 || string(vals["k"]) != strings.Repeat("k", 100) {
+		t.Fatalf("expect getmulti to decompress, got %v, %v", vals, err)
+	}
+}
+
+func TestPeerRetries(t *testing.T) {
+	g := NewGroup("retry", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return nil, errors.New("local load") }),
+		WithPeerRetries(3, time.Millisecond))
+	o := newTestOwner(t, "retry", GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }), nil)
+
+	var calls atomic.Int64
+	pool := o.pool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/gone"):
+			http.Error(w, "gone", http.StatusGone)
+		case n <= 2:
+			http.Error(w, "blip", http.StatusServiceUnavailable)
+		default:
+			pool.ServeHTTP(w, r)
+		}
+	}))
+	defer srv.Close()
+	g.RegisterPeers(&testPicker{peer: &httpGetter{baseURL: srv.URL + defaultBasePath}})
+
+	if v, err := g.Get("k"); err != nil || v.String() != "k" {
+		t.Fatalf("expect the fetch to succeed after retries, got %q, %v", v, err)
+	}
+	if s := g.Stats(); s.PeerRetries != 2 || s.PeerErrors != 0 {
+		t.Fatalf("expect two retries and no error, got %+v", s)
+	}
+
+	calls.Store(10)
+	if _, err := g.Get("gone"); err == nil {
+		t.Fatal("expect a 4xx to fail")
+	}
+	if n := calls.Load(); n != 11 {
+		t.Fatalf("expect a 4xx not to be retried, got %d calls", n-10)
+	}
+
+	calls.Store(0)
+	if views, err := g.GetMulti([]string{"m"}); err != nil || views["m"].String() != "m" {
+		t.Fatalf("expect the batch to succeed after retries, got %v, %v", views, err)
+	}
+	if s := g.Stats(); s.PeerRetries != 4 {
+		t.Fatalf("expect the batch to be retried twice, got %d retries in total", s.PeerRetries)
+	}
+}
+
+func TestPeerTimeout(t *testing.T) {
+	g := NewGroup("peertimeout", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("local"), nil }),
+		WithPeerTimeout(20*time.Millisecond))
+	unblock := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-unblock
+	}))
+	defer srv.Close()
+	defer close(unblock)
+	g.RegisterPeers(&testPicker{peer: &httpGetter{baseURL: srv.URL + defaultBasePath}})
+
+	start := time.Now()
+	if _, err := g.Get("k"); !errors.Is(err, ErrPeerTimeout) {
+		t.Fatalf("expect ErrPeerTimeout, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expect the slow peer to be abandoned after the timeout, took %v", d)
+	}
+	start = time.Now()
+	_, err := g.GetMulti([]string{"m1", "m2"})
+	var be BatchError
+	if !errors.As(err, &be) || !errors.Is(be["m1"], ErrPeerTimeout) || !errors.Is(be["m2"], ErrPeerTimeout) {
+		t.Fatalf("expect every batched key to time out, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("expect the slow peer to be abandoned by GetMulti too, took %v", d)
+	}
+
+	// 超时属于瞬时失败，可回退到本节点回源
+	g.peerFallback = true
+	if v, err := g.Get("k2"); err != nil || v.String() != "local" {
+		t.Fatalf("expect a fallback load after the timeout, got %q, %v", v, err)
+	}
+}
+
+func TestPeerFallback(t *testing.T) {
+	var loads atomic.Int64
+	g := NewGroup("fallback", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		loads.Add(1)
+		return []byte(key), nil
+	}), WithPeerFallback(time.Minute))
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close() // 所属节点不可达
+	g.RegisterPeers(&testPicker{peer: &httpGetter{baseURL: srv.URL + defaultBasePath}})
+
+	v, info, err := g.GetWithInfo(context.Background(), "k")
+	if err != nil || v.String() != "k" || info.Source != SourceFallback {
+		t.Fatalf("expect a non-authoritative local load, got %q, %+v, %v", v, info, err)
+	}
+	if v, _ := g.Get("k"); v.String() != "k" || loads.Load() != 1 || g.Stats().FallbackLoads != 1 {
+		t.Fatalf("expect the fallback value to be cached, got %d loads", loads.Load())
+	}
+	if _, ok := g.mainCache.get("k"); ok {
+		t.Fatal("expect the fallback value to stay out of mainCache")
+	}
+}
+
+func TestSelfBenchmark(t *testing.T) {
+	srv := httptest.NewServer(NewAdminHandler(false))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/bench?duration=30ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var b BenchResult
+	if err := json.NewDecoder(res.Body).Decode(&b); err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("expect a benchmark result, got %v, %v", res.Status, err)
+	}
+	if b.Duration != 30*time.Millisecond || b.HitOpsPerSec <= 0 || b.AddEvictOpsPerSec <= 0 || b.MutexWaitPerSec < 0 {
+		t.Fatalf("unexpected result %+v", b)
+	}
+
+	benchRunning.Store(true)
+	rec := httptest.NewRecorder()
+	NewAdminHandler(false).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/bench", nil))
+	benchRunning.Store(false)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expect 409 while a benchmark is running, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	NewHTTPPool("self").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, defaultBasePath+"?op=bench", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expect peers not to be able to run the benchmark, got %d", rec.Code)
+	}
+}
+
+func TestGroupConfig(t *testing.T) {
+	g := NewGroup("config", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }),
+		WithShards(2), WithNegativeCache(time.Second), WithPeerRetries(2, time.Millisecond))
+	g.SetCacheBytes(1 << 10)
+	c := g.Config()
+	if c.CacheBytes != 1<<10 || c.Shards != 2 || c.NegativeTTL != time.Second || c.PeerRetries != 2 ||
+		c.Getter != "geecache.GetterFunc" || c.Peers != "" {
+		t.Fatalf("unexpected config %+v", c)
+	}
+
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+	res, err := http.Get(srv.URL + defaultBasePath + "?op=config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var configs map[string]GroupConfig
+	if err := json.NewDecoder(res.Body).Decode(&configs); err != nil {
+		t.Fatal(err)
+	}
+	if got := configs["config"]; got.CacheBytes != 1<<10 || got.Shards != 2 {
+		t.Fatalf("expect the endpoint to expose the group config, got %+v", got)
+	}
+}
+
+func TestBasePathMount(t *testing.T) {
+	NewGroup("mount", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	b := NewHTTPPool("b", WithBasePath("cache"), WithHealthPath("/healthz"))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/app", func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "app") })
+	b.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	a := NewHTTPPool("a", WithBasePath("/cache/"))
+	a.Set("a", srv.URL)
+	if v, err := a.getter(srv.URL).Get(context.Background(), "mount", "k"); err != nil || string(v) != "k" {
+		t.Fatalf("expect the mounted pool to serve peers, got %q, %v", v, err)
+	}
+	for path, want := range map[string]int{"/app": http.StatusOK, "/healthz": http.StatusOK, "/other": http.StatusNotFound} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Fatalf("expect %d for %s, got %v", want, path, res.Status)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	b.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/elsewhere", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expect Handler to 404 foreign paths, got %d", rec.Code)
+	}
+}
+
+func TestOwnerFailover(t *testing.T) {
+	NewGroup("failover", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	var up atomic.Bool
+	b := NewHTTPPool("b")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		b.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	a := NewHTTPPool("a", WithOwnerFailover(10*time.Millisecond))
+	a.Set("a", srv.URL)
+	key := ""
+	for i := 0; key == ""; i++ {
+		if _, ok := a.PickPeer(strconv.Itoa(i)); ok {
+			key = strconv.Itoa(i)
+		}
+	}
+	peer, _ := a.PickPeer(key)
+	if _, err := peer.Get(context.Background(), "failover", key); err == nil {
+		t.Fatal("expect the owner to be failing")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := a.PickPeer(key); ok {
+		t.Fatal("expect this node to be promoted after the threshold")
+	}
+
+	up.Store(true)
+	for i := 0; i < 100; i++ {
+		if _, ok := a.PickPeer(key); ok {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expect the original owner to get its keys back")
+}
+
+func TestOwnerFailback(t *testing.T) {
+	getter := GetterFunc(func(key string) ([]byte, error) { return []byte("0"), nil })
+	ownerReg, backupReg := NewRegistry(), NewRegistry()
+	owner := ownerReg.NewGroup("failback", 2<<10, getter)
+	backup := backupReg.NewGroup("failback", 2<<10, getter)
+	defer ownerReg.DestroyGroup("failback")
+	defer backupReg.DestroyGroup("failback")
+
+	var up atomic.Bool
+	b := NewHTTPPool("b", WithRegistry(ownerReg))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		b.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+	a := NewHTTPPool("a", WithRegistry(backupReg), WithOwnerFailover(10*time.Millisecond))
+	a.Set("a", srv.URL)
+	backup.RegisterPeers(a)
+	key := ""
+	for i := 0; key == ""; i++ {
+		if _, ok := a.PickPeer(strconv.Itoa(i)); ok {
+			key = strconv.Itoa(i)
+		}
+	}
+
+	if _, err := backup.Get(key); err == nil {
+		t.Fatal("expect the owner to be failing")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n, err := backup.Increment(key, 1); err != nil || n != 1 {
+		t.Fatalf("expect the promoted node to count locally, got %d (%v)", n, err)
+	}
+
+	up.Store(true)
+	h := a.getter(srv.URL)
+	for i := 0; h.probing.Load() && i < 100; i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := a.PickPeer(key); !ok {
+		t.Fatal("expect the original owner to get its keys back")
+	}
+	if n, err := owner.Increment(key, 5); err != nil || n != 5 {
+		t.Fatalf("expect the owner to count from its own origin, got %d (%v)", n, err)
+	}
+	if v, err := backup.Get(key); err != nil || v.String() != "5" {
+		t.Fatalf("expect the former backup to read the owner's value, got %q (%v)", v, err)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/wp-login.php")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var body errorResponse
+	err = json.NewDecoder(res.Body).Decode(&body)
+	res.Body.Close()
+	if err != nil || res.StatusCode != http.StatusNotFound || body.Code != http.StatusNotFound {
+		t.Fatalf("expect a JSON 404 for a foreign path, got %v, %+v, %v", res.Status, body, err)
+	}
+
+	peer := &httpGetter{baseURL: srv.URL + defaultBasePath}
+	_, err = peer.Get(context.Background(), "no-such-group", "k")
+	if err == nil || !strings.Contains(err.Error(), "no such group: no-such-group") {
+		t.Fatalf("expect the peer's message in the error, got %v", err)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	g := NewGroup("notfound", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return nil, errors.New("unused") }))
+	o := newTestOwner(t, "notfound", GetterFunc(func(key string) ([]byte, error) {
+		if key == "missing" {
+			return nil, fmt.Errorf("no row for %s: %w", key, ErrNotFound)
+		}
+		return nil, errors.New("db down")
+	}), []GroupOption{WithNegativeCache(time.Minute)})
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	for key, want := range map[string]int{"missing": http.StatusNotFound, "broken": http.StatusInternalServerError} {
+		res, err := http.Get(o.url + "notfound/" + key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Fatalf("expect %d for %s, got %v", want, key, res.Status)
+		}
+	}
+	// 第二次读取missing命中所属节点的负缓存，仍应识别为不存在
+	if _, err := g.Get("missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect ErrNotFound through the peer, got %v", err)
+	}
+	if _, err := g.Get("broken"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Fatalf("expect a plain failure for a broken backend, got %v", err)
+	}
+}
+
+func TestCacheStatusHeader(t *testing.T) {
+	NewGroup("status", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	srv := httptest.NewServer(NewHTTPPool("node-1", WithCacheStatus()))
+	defer srv.Close()
+
+	for _, want := range []string{StatusMiss, StatusHit} {
+		res, err := http.Get(srv.URL + defaultBasePath + "status/k")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if got := res.Header.Get(CacheStatusHeader); got != want {
+			t.Fatalf("expect %s, got %q", want, got)
+		}
+		if got := res.Header.Get(NodeHeader); got != "node-1" {
+			t.Fatalf("expect the node identity, got %q", got)
+		}
+	}
+
+	if got := CacheStatus(ByteView{e: time.Now().Add(-time.Second)}, true); got != StatusStale {
+		t.Fatalf("expect an expired hit to be STALE, got %s", got)
+	}
+	if got := CacheStatus(ByteView{src: SourcePeer}, false); got != StatusPeerHit {
+		t.Fatalf("expect a peer fetch to be PEER-HIT, got %s", got)
+	}
+}
+
+func TestSharedSecret(t *testing.T) {
+	NewGroup("auth", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	b := NewHTTPPool("b", WithSharedSecret([]byte("s3cret")))
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+
+	a := NewHTTPPool("a", WithSharedSecret([]byte("s3cret")))
+	a.Set("a", srv.URL)
+	if v, err := a.getter(srv.URL).Get(context.Background(), "auth", "k"); err != nil || string(v) != "k" {
+		t.Fatalf("expect a signed request to be served, got %q, %v", v, err)
+	}
+
+	for name, secret := range map[string][]byte{"unsigned": nil, "wrong secret": []byte("guess")} {
+		c := NewHTTPPool("c", WithSharedSecret(secret))
+		c.Set("c", srv.URL)
+		if _, err := c.getter(srv.URL).Get(context.Background(), "auth", "k"); err == nil {
+			t.Fatalf("expect a %s request to be rejected", name)
+		}
+	}
+
+	res, err := http.Get(srv.URL + defaultBasePath + "health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("expect the health check to stay open, got %v", res.Status)
+	}
+}
+
+func TestSplitBrain(t *testing.T) {
+	NewGroup("ring", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+
+	var reported string
+	b := NewHTTPPool("b", WithStrictOwnership(),
+		WithSplitBrainDetection(2, func(peer, peerRing, localRing string) { reported = peer }))
+	srv := httptest.NewServer(b)
+	defer srv.Close()
+	b.Set("b", srv.URL)
+
+	a := NewHTTPPool("a")
+	a.Set("a", srv.URL) // a does not know about b's view of the ring
+	peer := a.getter(srv.URL)
+
+	if _, err := peer.Get(context.Background(), "ring", "k"); err != nil {
+		t.Fatalf("expect reads to be served despite disagreement, got %v", err)
+	}
+	if _, err := peer.Increment(context.Background(), "ring", "n", 1); err == nil {
+		t.Fatal("expect strict ownership to refuse the increment")
+	}
+	if reported != "a" || b.RingMismatches() != 2 {
+		t.Fatalf("expect split brain with a reported after 2 mismatches, got %q after %d", reported, b.RingMismatches())
+	}
+
+	a.Set("b", srv.URL)
+	if _, err := peer.Increment(context.Background(), "ring", "n", 1); err != nil {
+		t.Fatalf("expect the increment once rings agree, got %v", err)
+	}
+}
+
+// upperSerializer is a serializer unknown to the server.
+type upperSerializer struct{ rawSerializer }
+
+func (upperSerializer) ContentType() string { return "application/x-upper" }
+
+func TestSerializers(t *testing.T) {
+	NewGroup("serializer", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte("value-" + key), nil }))
+	srv := httptest.NewServer(NewHTTPPool("self"))
+	defer srv.Close()
+
+	for _, sz := range []Serializer{nil, ProtoSerializer, RawSerializer, upperSerializer{}} {
+		peer := &httpGetter{baseURL: srv.URL + defaultBasePath, serializer: sz}
+		v, err := peer.Get(context.Background(), "serializer", "k")
+		if err != nil || string(v) != "value-k" {
+			t.Fatalf("%T: expect value-k, got %q (%v)", sz, v, err)
+		}
+	}
+}
+
+func TestRequestInfo(t *testing.T) {
+	g := NewGroup("tenant", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	var got RequestInfo
+	o := newTestOwner(t, "tenant", ContextGetterFunc(
+		func(ctx context.Context, key string) ([]byte, error) {
+			got, _ = RequestInfoFromContext(ctx)
+			return []byte(got.Tenant), nil
+		}), nil)
+	peer := &httpGetter{baseURL: o.url, self: "node-a", ring: func() string { return "" }}
+	g.RegisterPeers(&testPicker{peer: peer})
+
+	ctx := WithRequestInfo(context.Background(), RequestInfo{Tenant: "acme", TraceID: "trace-1"})
+	if v, err := g.GetContext(ctx, "k"); err != nil || v.String() != "acme" {
+		t.Fatalf("expect the owner's getter to see tenant acme, got %q (%v)", v, err)
+	}
+	want := RequestInfo{Peer: "node-a", Tenant: "acme", TraceID: "trace-1"}
+	if got != want {
+		t.Fatalf("expect %+v, got %+v", want, got)
+	}
+}
+
+func TestGetWithMaxStale(t *testing.T) {
+	g := NewGroup("maxstale", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return nil, errors.New("not the owner") }))
+	version := 0
+	o := newTestOwner(t, "maxstale", GetterFunc(
+		func(key string) ([]byte, error) {
+			version++
+			return []byte(fmt.Sprint(version)), nil
+		}), nil)
+	g.RegisterPeers(&testPicker{peer: o.peer()})
+
+	ctx := context.Background()
+	if v, err := g.GetWithMaxStale(ctx, "k", time.Hour); err != nil || v.String() != "1" {
+		t.Fatalf("expect 1, got %q (%v)", v, err)
+	}
+	if v, _ := g.GetWithMaxStale(ctx, "k", time.Hour); v.String() != "1" {
+		t.Fatalf("expect the owner's cached value within maxStale, got %q", v)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if v, _ := g.GetWithMaxStale(ctx, "k", time.Millisecond); v.String() != "2" {
+		t.Fatalf("expect the owner to refresh a value older than maxStale, got %q", v)
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	g := NewGroup("admin", 2<<10, GetterFunc(func(key string) ([]byte, error) {
+		return []byte(key), nil
+	}))
+	g.Get("a")
+	g.Get("b")
+	srv := httptest.NewServer(NewAdminHandler(true))
+	defer srv.Close()
+
+	get := func(path string, v any) int {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if v != nil && res.StatusCode == http.StatusOK {
+			if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return res.StatusCode
+	}
+
+	var stats map[string]CacheStats
+	if get("/stats", &stats); stats["admin"].Gets != 2 {
+		t.Fatalf("expect the group's stats, got %+v", stats["admin"])
+	}
+	var keys []string
+	if get("/keys?group=admin&n=5", &keys); len(keys) != 2 {
+		t.Fatalf("expect both keys, got %v", keys)
+	}
+	if code := get("/keys?group=missing", nil); code != http.StatusNotFound {
+		t.Fatalf("expect 404 for an unknown group, got %d", code)
+	}
+	var configs map[string]GroupConfig
+	if get("/config", &configs); configs["admin"].Name != "admin" {
+		t.Fatalf("expect the group's config, got %+v", configs["admin"])
+	}
+	if code := get("/debug/pprof/", nil); code != http.StatusOK {
+		t.Fatalf("expect pprof to be mounted, got %d", code)
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	a, b := NewRegistry(), NewRegistry()
+	a.NewGroup("universe", 2<<10, GetterFunc(func(key string) ([]byte, error) { return []byte("a"), nil }))
+	b.NewGroup("universe", 2<<10, GetterFunc(func(key string) ([]byte, error) { return []byte("b"), nil }))
+	if GetGroup("universe") != nil {
+		t.Fatal("expect the default registry to be unaffected")
+	}
+	if v, _ := b.GetGroup("universe").Get("k"); v.String() != "b" {
+		t.Fatalf("expect each registry to keep its own group, got %q", v)
+	}
+
+	p := NewHTTPPool("http://self", WithRegistry(a))
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, defaultBasePath+"universe/k", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "a" {
+		t.Fatalf("expect the pool to serve its registry, got %d %q", rec.Code, rec.Body)
+	}
+	if h := p.Health(); h.Groups != 1 {
+		t.Fatalf("expect health to count the registry's groups, got %d", h.Groups)
+	}
+
+	rec = httptest.NewRecorder()
+	b.AdminHandler(false).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/keys?group=universe", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"k"`) {
+		t.Fatalf("expect the admin handler to serve its registry, got %d %q", rec.Code, rec.Body)
+	}
+	if err := b.PublishExpvar("geecache-registry-test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.PublishExpvar("geecache-registry-test"); err == nil {
+		t.Fatal("expect publishing under a taken name to fail")
+	}
+	if s := expvar.Get("geecache-registry-test").String(); !strings.Contains(s, `"universe":{`) {
+		t.Fatalf("expect the registry's groups under expvar, got %s", s)
+	}
+}
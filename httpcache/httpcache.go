@@ -0,0 +1,294 @@
+// Package httpcache 提供以 geecache.Group 作为HTTP响应缓存的 http.RoundTripper
+//
+// 同进程内的HTTP客户端流量可借此复用分布式缓存：相同的响应在集群内只从上游获取一次。
+//
+// 典型用法：
+//
+//	t := httpcache.New("http-responses", 64<<20, http.DefaultTransport)
+//	t.Group().RegisterPeers(pool)
+//	client := &http.Client{Transport: t}
+package httpcache
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"strings"
+	"time"
+
+	"github/lhh-gh/geecache"
+)
+
+// expiresHeader 记录缓存响应的新鲜期截止时间（Unix纳秒），随响应一起存入Group
+const expiresHeader = "X-Geecache-Expires"
+
+// Transport 以Group缓存GET响应的 http.RoundTripper
+// 缓存规则：
+//  1. 只缓存不带Authorization/Range的GET请求，key为 方法+空格+URL
+//  2. 只缓存200响应，且响应未声明 no-store/no-cache/private；
+//     新鲜期取 Cache-Control 的 s-maxage/max-age，其次为 Expires，都没有时视为已过期
+//  3. 过期的响应带ETag时以 If-None-Match 向上游验证，304则刷新新鲜期后复用
+//  4. 请求带 Cache-Control: no-cache/no-store 时绕过缓存
+//
+// 注意：不处理Vary，响应内容随请求头变化的上游不应经过该Transport
+type Transport struct {
+	// StatusHeader 为true时在响应上添加 geecache.CacheStatusHeader（HIT/MISS/STALE/PEER-HIT/BYPASS）
+	StatusHeader bool
+	// Node 非空时在响应上添加 geecache.NodeHeader
+	Node string
+
+	group *geecache.Group
+	next  http.RoundTripper
+	now   func() time.Time // 可注入以获得确定性
+}
+
+// uncacheableError 上游响应不可缓存时由加载器返回，本地加载时携带原响应避免重复请求
+type uncacheableError struct {
+	dump []byte
+}
+
+func (e *uncacheableError) Error() string { return "httpcache: uncacheable response" }
+
+type requestKey struct{}
+
+// New 创建名为name的Group并返回以其缓存响应的Transport
+// next 为nil时使用 http.DefaultTransport
+func New(name string, cacheBytes int64, next http.RoundTripper, opts ...geecache.GroupOption) *Transport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	t := &Transport{next: next, now: time.Now}
+	t.group = geecache.NewGroup(name, cacheBytes, geecache.ContextGetterFunc(t.load), opts...)
+	return t
+}
+
+// Group 返回底层Group，用于注册节点或查看统计
+func (t *Transport) Group() *geecache.Group {
+	return t.group
+}
+
+// RoundTrip 实现 http.RoundTripper
+// 缓存层出错时直接请求上游，缓存不可用不影响HTTP调用本身
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheableRequest(req) {
+		return t.mark(t.next.RoundTrip(req))(geecache.StatusBypass)
+	}
+	key := req.Method + " " + req.URL.String()
+	ctx := context.WithValue(req.Context(), requestKey{}, req)
+	view, info, err := t.group.GetWithInfo(ctx, key)
+	if err != nil {
+		var ue *uncacheableError
+		if errors.As(err, &ue) {
+			return t.mark(readResponse(ue.dump, req))(geecache.StatusMiss)
+		}
+		return t.mark(t.next.RoundTrip(req))(geecache.StatusBypass)
+	}
+
+	dump := view.ByteSlice()
+	resp, err := readResponse(dump, req)
+	if err != nil {
+		return t.mark(t.next.RoundTrip(req))(geecache.StatusBypass)
+	}
+	if t.fresh(resp) {
+		return t.mark(resp, nil)(geecache.CacheStatus(view, info.Hit))
+	}
+	return t.revalidate(req, key, resp, dump)
+}
+
+// mark 按配置为响应添加缓存状态与节点头，用法：t.mark(resp, err)(status)
+func (t *Transport) mark(resp *http.Response, err error) func(status string) (*http.Response, error) {
+	return func(status string) (*http.Response, error) {
+		if err != nil {
+			return nil, err
+		}
+		if t.StatusHeader {
+			resp.Header.Set(geecache.CacheStatusHeader, status)
+		}
+		if t.Node != "" {
+			resp.Header.Set(geecache.NodeHeader, t.Node)
+		}
+		return resp, nil
+	}
+}
+
+// revalidate 过期响应的处理：有ETag时做条件请求，否则重新获取
+func (t *Transport) revalidate(req *http.Request, key string, cached *http.Response, dump []byte) (*http.Response, error) {
+	etag := cached.Header.Get("ETag")
+	if etag == "" {
+		cached.Body.Close()
+		return t.mark(t.refetch(req, key))(geecache.StatusMiss)
+	}
+	cond := req.Clone(req.Context())
+	cond.Header.Set("If-None-Match", etag)
+	resp, err := t.next.RoundTrip(cond)
+	if err != nil {
+		return t.mark(cached, nil)(geecache.StatusStale) // 上游不可用时返回过期响应
+	}
+	if resp.StatusCode != http.StatusNotModified {
+		cached.Body.Close()
+		return t.mark(t.store(req, key, resp))(geecache.StatusMiss)
+	}
+	resp.Body.Close()
+
+	// 304：以新响应头刷新新鲜期后写回
+	for _, h := range []string{"Cache-Control", "Expires", "Date", "ETag"} {
+		if v := resp.Header.Get(h); v != "" {
+			cached.Header.Set(h, v)
+		}
+	}
+	if expires, ok := t.expires(cached.Header); ok {
+		cached.Header.Set(expiresHeader, strconv.FormatInt(expires.UnixNano(), 10))
+		if refreshed, err := dumpResponse(cached); err == nil {
+			t.group.Set(key, refreshed)
+			return t.mark(readResponse(refreshed, req))(geecache.StatusHit)
+		}
+	}
+	return t.mark(readResponse(dump, req))(geecache.StatusHit)
+}
+
+// refetch 重新请求上游并按缓存规则更新Group
+func (t *Transport) refetch(req *http.Request, key string) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	return t.store(req, key, resp)
+}
+
+// store 可缓存的响应写入Group，否则删除旧条目；返回可读取的响应副本
+func (t *Transport) store(req *http.Request, key string, resp *http.Response) (*http.Response, error) {
+	dump, cacheable, err := t.encode(resp)
+	if err != nil {
+		return nil, err
+	}
+	if cacheable {
+		t.group.Set(key, dump)
+	} else {
+		t.group.Remove(key)
+	}
+	return readResponse(dump, req)
+}
+
+// load 作为Group的加载器：向上游获取key对应的响应
+// 由本节点发起时复用调用方的请求（保留请求头），由其他节点转发时按key重建请求
+func (t *Transport) load(ctx context.Context, key string) ([]byte, error) {
+	req, _ := ctx.Value(requestKey{}).(*http.Request)
+	if req == nil {
+		method, url, ok := strings.Cut(key, " ")
+		if !ok {
+			return nil, fmt.Errorf("httpcache: malformed key %q", key)
+		}
+		var err error
+		if req, err = http.NewRequestWithContext(ctx, method, url, nil); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	dump, cacheable, err := t.encode(resp)
+	if err != nil {
+		return nil, err
+	}
+	if !cacheable {
+		return nil, &uncacheableError{dump: dump}
+	}
+	return dump, nil
+}
+
+// encode 读取并关闭响应体，返回序列化后的响应及是否可缓存
+func (t *Transport) encode(resp *http.Response) ([]byte, bool, error) {
+	cacheable := cacheableResponse(resp)
+	if cacheable {
+		expires, _ := t.expires(resp.Header)
+		resp.Header.Set(expiresHeader, strconv.FormatInt(expires.UnixNano(), 10))
+	}
+	dump, err := dumpResponse(resp)
+	return dump, cacheable, err
+}
+
+// expires 计算响应的新鲜期截止时间，无法确定时返回当前时间与false
+func (t *Transport) expires(h http.Header) (time.Time, bool) {
+	now := t.now()
+	cc := parseCacheControl(h.Get("Cache-Control"))
+	for _, d := range []string{"s-maxage", "max-age"} {
+		if v, ok := cc[d]; ok {
+			if secs, err := strconv.Atoi(v); err == nil {
+				return now.Add(time.Duration(secs) * time.Second), true
+			}
+		}
+	}
+	if v := h.Get("Expires"); v != "" {
+		if exp, err := http.ParseTime(v); err == nil {
+			return exp, true
+		}
+	}
+	return now, false
+}
+
+// fresh 判断缓存的响应是否仍在新鲜期内
+func (t *Transport) fresh(resp *http.Response) bool {
+	ns, err := strconv.ParseInt(resp.Header.Get(expiresHeader), 10, 64)
+	return err == nil && t.now().Before(time.Unix(0, ns))
+}
+
+func cacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" || req.Header.Get("Range") != "" {
+		return false
+	}
+	cc := parseCacheControl(req.Header.Get("Cache-Control"))
+	_, noCache := cc["no-cache"]
+	_, noStore := cc["no-store"]
+	return !noCache && !noStore
+}
+
+func cacheableResponse(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	cc := parseCacheControl(resp.Header.Get("Cache-Control"))
+	for _, d := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := cc[d]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCacheControl 解析Cache-Control指令，指令名统一为小写
+func parseCacheControl(v string) map[string]string {
+	cc := make(map[string]string)
+	for _, part := range strings.Split(v, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		cc[strings.ToLower(strings.TrimSpace(name))] = strings.Trim(strings.TrimSpace(value), `"`)
+	}
+	return cc
+}
+
+// dumpResponse 读取并关闭响应体，将完整响应序列化为HTTP/1.1报文
+func dumpResponse(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	resp.ContentLength = int64(len(body))
+	resp.TransferEncoding = nil
+	return httputil.DumpResponse(resp, true)
+}
+
+func readResponse(dump []byte, req *http.Request) (*http.Response, error) {
+	return http.ReadResponse(bufio.NewReader(bytes.NewReader(dump)), req)
+}
@@ -0,0 +1,116 @@
+package httpcache
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github/lhh-gh/geecache"
+)
+
+func get(t *testing.T, client *http.Client, url string) string {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestTransport(t *testing.T) {
+	var hits, revalidations atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		switch r.URL.Path {
+		case "/fresh":
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				revalidations.Add(1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+		case "/nostore":
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	now := time.Now()
+	tr := New("httpcache", 1<<20, nil)
+	tr.now = func() time.Time { return now }
+	client := &http.Client{Transport: tr}
+
+	for i := 0; i < 3; i++ {
+		if body := get(t, client, srv.URL+"/fresh"); body != "/fresh" {
+			t.Fatalf("expect /fresh, got %q", body)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Fatalf("expect one upstream request for a fresh response, got %d", n)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if body := get(t, client, srv.URL+"/fresh"); body != "/fresh" {
+		t.Fatalf("expect the revalidated body, got %q", body)
+	}
+	get(t, client, srv.URL+"/fresh")
+	if n := revalidations.Load(); n != 1 {
+		t.Fatalf("expect one conditional request after expiry, got %d", n)
+	}
+
+	hits.Store(0)
+	for i := 0; i < 2; i++ {
+		if body := get(t, client, srv.URL+"/nostore"); body != "/nostore" {
+			t.Fatalf("expect /nostore, got %q", body)
+		}
+	}
+	if n := hits.Load(); n != 2 {
+		t.Fatalf("expect no-store responses to bypass the cache, got %d upstream requests", n)
+	}
+}
+
+func TestStatusHeader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	tr := New("httpcache-status", 1<<20, nil)
+	tr.StatusHeader, tr.Node = true, "node-1"
+	client := &http.Client{Transport: tr}
+
+	for _, want := range []string{geecache.StatusMiss, geecache.StatusHit} {
+		resp, err := client.Get(srv.URL + "/a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if got := resp.Header.Get(geecache.CacheStatusHeader); got != want {
+			t.Fatalf("expect %s, got %q", want, got)
+		}
+		if got := resp.Header.Get(geecache.NodeHeader); got != "node-1" {
+			t.Fatalf("expect the node identity, got %q", got)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/a", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get(geecache.CacheStatusHeader); got != geecache.StatusBypass {
+		t.Fatalf("expect BYPASS for a POST, got %q", got)
+	}
+}
@@ -0,0 +1,89 @@
+package geecache
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxErrorBody bounds how much of an error response a client reads.
+const maxErrorBody = 4 << 10
+
+// reasonNotFound marks a 404 for a missing key, as opposed to a missing
+// group or path.
+const reasonNotFound = "not_found"
+
+// errorResponse is the body of every error the pool answers with.
+type errorResponse struct {
+	Error  string `json:"error"`
+	Code   int    `json:"code"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// writeError replies with a JSON error body, so clients can tell the
+// pool's errors apart from the HTML pages of proxies in between.
+func writeError(w http.ResponseWriter, msg string, code int) {
+	writeErrorReason(w, msg, code, "")
+}
+
+// writeLoadError answers a failed get: 404 when the key does not exist
+// (ErrNotFound), 504 when a peer it was forwarded to timed out
+// (ErrPeerTimeout), 500 for any other failure.
+func writeLoadError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		writeErrorReason(w, err.Error(), http.StatusNotFound, reasonNotFound)
+		return
+	}
+	if errors.Is(err, ErrPeerTimeout) {
+		writeError(w, err.Error(), http.StatusGatewayTimeout)
+		return
+	}
+	writeError(w, err.Error(), http.StatusInternalServerError)
+}
+
+func writeErrorReason(w http.ResponseWriter, msg string, code int, reason string) {
+	h := w.Header()
+	h.Del("Content-Length")
+	h.Set("Content-Type", "application/json")
+	h.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(errorResponse{Error: msg, Code: code, Reason: reason})
+}
+
+// statusError is returned by peer clients for unexpected response statuses.
+type statusError struct {
+	code   int
+	status string
+	msg    string // the peer's error message, if it sent one
+	reason string
+}
+
+// Unwrap lets errors.Is(err, ErrNotFound) see through a peer's 404.
+func (e *statusError) Unwrap() error {
+	if e.reason == reasonNotFound {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (e *statusError) Error() string {
+	if e.msg == "" {
+		return "server returned: " + e.status
+	}
+	return "server returned: " + e.status + ": " + e.msg
+}
+
+// serverError builds the error for an unexpected response, picking up the
+// message of a JSON error body.
+func serverError(res *http.Response) error {
+	e := &statusError{code: res.StatusCode, status: res.Status}
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/json") {
+		var body errorResponse
+		if json.NewDecoder(io.LimitReader(res.Body, maxErrorBody)).Decode(&body) == nil {
+			e.msg, e.reason = body.Error, body.Reason
+		}
+	}
+	return e
+}
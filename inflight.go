@@ -0,0 +1,78 @@
+package geecache
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// inflightLoads 跟踪进行中的加载，使与之竞争的删除生效
+// 问题：删除在加载进行中到达时，加载稍后回填的仍是删除前的数据，删除等于没做。
+// 做法：加载开始时登记，删除时标记该key所有进行中的加载作废；
+// 作废的加载结果照常返回给已在等待的调用方，但不回填任何缓存
+type inflightLoads struct {
+	mu    sync.Mutex
+	loads map[string]map[*inflightLoad]struct{}
+}
+
+// inflightLoad 一次进行中的加载
+type inflightLoad struct {
+	key         string
+	invalidated atomic.Bool
+}
+
+// begin 登记key的一次加载，加载结束时必须调用end
+func (l *inflightLoads) begin(key string) *inflightLoad {
+	load := &inflightLoad{key: key}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.loads == nil {
+		l.loads = make(map[string]map[*inflightLoad]struct{})
+	}
+	if l.loads[key] == nil {
+		l.loads[key] = make(map[*inflightLoad]struct{})
+	}
+	l.loads[key][load] = struct{}{}
+	return load
+}
+
+// end 注销加载，返回其结果是否仍可缓存（期间未被删除作废）
+func (l *inflightLoads) end(load *inflightLoad) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if set := l.loads[load.key]; set != nil {
+		delete(set, load)
+		if len(set) == 0 {
+			delete(l.loads, load.key)
+		}
+	}
+	return !load.invalidated.Load()
+}
+
+// keys 返回有进行中加载的key
+func (l *inflightLoads) keys() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	keys := make([]string, 0, len(l.loads))
+	for key := range l.loads {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// invalidate 作废key所有进行中的加载
+func (l *inflightLoads) invalidate(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for load := range l.loads[key] {
+		load.invalidated.Store(true)
+	}
+}
+
+// cacheable 结束加载并报告结果能否回填缓存，作废的加载计入统计
+func (g *Group) cacheable(load *inflightLoad) bool {
+	if g.inflight.end(load) {
+		return true
+	}
+	g.stats.loadsInvalidated.Add(1)
+	return false
+}
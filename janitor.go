@@ -0,0 +1,28 @@
+package geecache
+
+import "time"
+
+// WithJanitor 启动后台清理任务，每隔interval清除已过期的条目
+// 惰性过期只在key再次被读取时回收空间，从不再读取的过期条目会一直占用容量
+// 直到被LRU淘汰；清理任务补充这一点。启用过期后台刷新模式时，
+// 仍在可返回旧值时长内的条目会被保留。清理任务随Group.Close停止
+func WithJanitor(interval time.Duration) GroupOption {
+	return func(g *Group) {
+		if interval > 0 {
+			g.janitorInterval = interval
+		}
+	}
+}
+
+// sweepExpired 清除mainCache、hotCache与负缓存中的过期条目
+// 数据源不可用期间保留mainCache中的过期条目
+func (g *Group) sweepExpired() {
+	now := time.Now()
+	var n int
+	if g.OriginHealthy() {
+		n = g.mainCache.removeExpired(now.Add(-g.staleWindow))
+	}
+	n += g.hotCache.removeExpired(now)
+	n += g.negCache.removeExpired(now)
+	g.stats.expiredSwept.Add(int64(n))
+}
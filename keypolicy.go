@@ -0,0 +1,92 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultPolicyBackoff LoadPolicy要求重试而Group未配置WithPeerRetries时的基础等待时长
+const defaultPolicyBackoff = 50 * time.Millisecond
+
+// LoadPolicy 单个key的加载策略
+// 同一Group内的key代价可能相差悬殊（如报表与单行查询），按key区分超时、
+// 重试预算与并发类别，避免重量级key拖垮轻量key
+type LoadPolicy struct {
+	Timeout time.Duration // 一次加载（远端获取或回源）的超时，0表示只受调用方ctx约束
+	Retries int           // 远端获取的重试次数，0表示沿用WithPeerRetries，负数表示不重试
+	Class   string        // 回源的并发类别（见WithLoadClass），空表示不限制
+}
+
+// KeyPolicy 为key给出加载策略
+// Getter同时实现该接口时自动启用（WithKeyPolicy优先）。
+// 每次加载可能调用多次，实现应廉价且无副作用
+type KeyPolicy interface {
+	LoadPolicy(key string) LoadPolicy
+}
+
+// KeyPolicyFunc 函数类型适配器，允许普通函数实现KeyPolicy接口
+type KeyPolicyFunc func(key string) LoadPolicy
+
+// LoadPolicy 实现KeyPolicy接口方法
+func (f KeyPolicyFunc) LoadPolicy(key string) LoadPolicy {
+	return f(key)
+}
+
+// WithKeyPolicy 设置按key区分的加载策略
+func WithKeyPolicy(policy KeyPolicy) GroupOption {
+	return func(g *Group) {
+		g.keyPolicy = policy
+	}
+}
+
+// WithLoadClass 定义并发类别：LoadPolicy.Class为class的key同时最多limit个在回源
+// 超出的加载排队等待，直到轮到或ctx结束。limit<=0 时不定义
+func WithLoadClass(class string, limit int) GroupOption {
+	return func(g *Group) {
+		if limit <= 0 {
+			return
+		}
+		if g.loadClasses == nil {
+			g.loadClasses = make(map[string]chan struct{})
+		}
+		g.loadClasses[class] = make(chan struct{}, limit)
+	}
+}
+
+// loadPolicy 返回key的加载策略，未配置时为零值
+func (g *Group) loadPolicy(key string) LoadPolicy {
+	if g.keyPolicy == nil {
+		return LoadPolicy{}
+	}
+	return g.keyPolicy.LoadPolicy(key)
+}
+
+// peerRetriesFor 按策略解析远端获取的重试次数与基础等待时长
+func (g *Group) peerRetriesFor(policy LoadPolicy) (int, time.Duration) {
+	switch {
+	case policy.Retries < 0:
+		return 0, 0
+	case policy.Retries == 0:
+		return g.peerRetries, g.peerBackoff
+	case g.peerBackoff > 0:
+		return policy.Retries, g.peerBackoff
+	default:
+		return policy.Retries, defaultPolicyBackoff
+	}
+}
+
+// acquireClass 占用key所属并发类别的一个名额，返回释放函数
+// 未定义的类别不限制
+func (g *Group) acquireClass(ctx context.Context, key string) (release func(), err error) {
+	sem := g.loadClasses[g.loadPolicy(key).Class]
+	if sem == nil {
+		return func() {}, nil
+	}
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("waiting for load class: %w", ctx.Err())
+	}
+}
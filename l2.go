@@ -0,0 +1,220 @@
+package geecache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// L2 内存LRU之后、Getter之前查询的第二级缓存（通常在磁盘上）
+// 用于数据集大于内存的场景：mainCache因容量淘汰的条目写回L2，
+// 再次访问时从L2读取而不必回源
+//
+// 实现必须并发安全；expire为零值表示永不过期
+type L2 interface {
+	// Get 返回key的值与过期时间，不存在时ok为false
+	Get(key string) (value []byte, expire time.Time, ok bool)
+	// Put 写入或覆盖key的值
+	Put(key string, value []byte, expire time.Time) error
+	// Remove 删除key，不存在时不报错
+	Remove(key string) error
+	// Clear 删除所有条目
+	Clear() error
+}
+
+// WithL2 为Group配置第二级缓存
+// 行为：
+//  1. 本节点负责的key在mainCache未命中时先查L2，命中则移入mainCache（L2中的副本删除）
+//  2. mainCache因容量淘汰未过期的条目时写回L2（后台任务异步写入，不占用分片锁）
+//  3. 本地的任何写入（回填、Set、CAS、Append、Increment）删除L2中的旧副本，
+//     Remove与Clear同时作用于L2
+//  4. CAS、Append、Increment在mainCache未命中时以L2中的值为旧值，被淘汰的条目不会被当作不存在
+//
+// 因此同一key在mainCache与L2中至多有一份，mainCache中的值过期后不会读到L2中更旧的值
+func WithL2(l2 L2) GroupOption {
+	return func(g *Group) {
+		g.l2 = l2
+	}
+}
+
+// getL2 从L2读取未过期的值
+func (g *Group) getL2(key string) (ByteView, bool) {
+	if g.l2 == nil {
+		return ByteView{}, false
+	}
+	b, expire, ok := g.l2.Get(key)
+	if !ok || (!expire.IsZero() && !time.Now().Before(expire)) {
+		return ByteView{}, false
+	}
+	g.stats.l2Hits.Add(1)
+	return ByteView{b: b, e: expire, src: SourceL2}, true
+}
+
+// loadL2 从L2读取key并回填内存缓存，命中时结束load（加载期间被删除则不回填）
+// 未命中或解码失败时load保持进行，由调用方继续回源
+func (g *Group) loadL2(key string, load *inflightLoad) (ByteView, bool) {
+	stored, ok := g.getL2(key)
+	if !ok {
+		return ByteView{}, false
+	}
+	value, outdated, err := g.decode(key, stored)
+	if err != nil {
+		g.cacheFailed(err)
+		return ByteView{}, false
+	}
+	if !g.cacheable(load) {
+		return value, true
+	}
+	if outdated {
+		g.populateCache(key, value)
+	} else {
+		g.populateEncoded(key, stored)
+	}
+	return value, true
+}
+
+// writeBack mainCache容量淘汰时将条目写回L2
+// 在分片锁内被调用，文件读写交给后台任务；后台配额用尽时放弃写回（L2只是缓存）。
+// 写回登记在l2Pending中，任务开始前key被写入或删除（dropL2）则放弃，不会把旧值写回L2
+func (g *Group) writeBack(key string, value ByteView, reason EvictReason) {
+	if reason != EvictCapacity || value.expired(time.Now()) {
+		return
+	}
+	token := &value // 登记的值同时供pendingL2读取
+	g.l2Pending.Store(key, token)
+	started := g.bg.goTask(func(context.Context) {
+		defer g.l2Locks.lock(key)()
+		if !g.l2Pending.CompareAndDelete(key, token) {
+			return
+		}
+		if err := g.l2.Put(key, value.b, value.e); err != nil {
+			g.logger().Warn("writing back to L2", "group", g.name, "key", key, "err", err)
+			g.cacheFailed(err)
+		}
+	})
+	if !started {
+		g.l2Pending.CompareAndDelete(key, token)
+	}
+}
+
+// pendingL2 返回已登记、尚未写入L2的值
+// 须在key所在分片的锁内调用，与writeBack的登记互斥
+func (g *Group) pendingL2(key string) (ByteView, bool) {
+	token, ok := g.l2Pending.Load(key)
+	if !ok {
+		return ByteView{}, false
+	}
+	value := *token.(*ByteView)
+	if value.expired(time.Now()) {
+		return ByteView{}, false
+	}
+	return value, true
+}
+
+// dropL2 删除key在L2中的副本并取消尚未执行的写回，本地写入或删除key后调用
+func (g *Group) dropL2(key string) {
+	if g.l2 == nil {
+		return
+	}
+	defer g.l2Locks.lock(key)()
+	g.removeL2(key)
+}
+
+// removeL2 dropL2的实现，调用方持有l2Locks
+func (g *Group) removeL2(key string) {
+	g.l2Pending.Delete(key)
+	if err := g.l2.Remove(key); err != nil {
+		g.logger().Warn("removing from L2", "group", g.name, "key", key, "err", err)
+		g.cacheFailed(err)
+	}
+}
+
+// DirL2 以目录存储条目的L2实现，每个key一个文件
+// 文件名为key的SHA-256，内容沿用持久化格式（见persist.go），读取时校验key
+// 注意：不限制占用的磁盘空间，过期条目在读取时删除
+type DirL2 struct {
+	dir string
+}
+
+// NewDirL2 创建以dir为存储目录的L2，目录不存在时自动创建
+func NewDirL2(dir string) (*DirL2, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirL2{dir: dir}, nil
+}
+
+func (d *DirL2) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:]))
+}
+
+// Get 实现L2
+func (d *DirL2) Get(key string) ([]byte, time.Time, bool) {
+	data, err := os.ReadFile(d.path(key))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	var (
+		value ByteView
+		found bool
+	)
+	readEntries(bytes.NewReader(data), func(k string, v ByteView) {
+		if k == key {
+			value, found = v, true
+		}
+	})
+	if !found {
+		os.Remove(d.path(key)) // 已过期或已损坏
+		return nil, time.Time{}, false
+	}
+	return value.b, value.e, true
+}
+
+// Put 实现L2，先写临时文件再改名，读取方不会看到写了一半的条目
+func (d *DirL2) Put(key string, value []byte, expire time.Time) error {
+	var buf bytes.Buffer
+	if err := writeEntries(&buf, []snapshotEntry{{key: key, value: ByteView{b: value, e: expire}}}); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), d.path(key))
+}
+
+// Remove 实现L2
+func (d *DirL2) Remove(key string) error {
+	if err := os.Remove(d.path(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Clear 实现L2
+func (d *DirL2) Clear() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.Remove(filepath.Join(d.dir, e.Name())); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
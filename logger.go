@@ -0,0 +1,51 @@
+package geecache
+
+import "log/slog"
+
+// Logger 带级别的结构化日志接口，*slog.Logger 满足该接口
+// args 为交替的键值对（或slog.Attr），与slog相同
+//
+// 级别约定：
+//   - Debug 每个请求都会产生的日志（缓存命中、节点选择、收到的请求）
+//   - Info  节点加入/离开等低频状态变化
+//   - Warn  可自动恢复的问题（编码失败、节点故障、数据源不健康）
+//   - Error 需要人工处理的问题
+//
+// 未配置时使用slog.Default()（默认Info级别，因此请求级日志默认不输出）
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// WithLogger 为Group配置日志输出
+func WithLogger(l Logger) GroupOption {
+	return func(g *Group) {
+		g.log = l
+	}
+}
+
+// WithPoolLogger 为HTTPPool配置日志输出
+func WithPoolLogger(l Logger) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.log = l
+	}
+}
+
+// logger 返回Group的日志输出
+func (g *Group) logger() Logger {
+	if g.log == nil {
+		return slog.Default()
+	}
+	return g.log
+}
+
+// logger returns the pool's logger. Entries carry the pool's own address
+// as "self", so the logs of several pools in one process can be told apart.
+func (p *HTTPPool) logger() Logger {
+	if p.log == nil {
+		return slog.Default()
+	}
+	return p.log
+}
@@ -9,7 +9,37 @@ type Cache struct {
 	nbytes    int64                         // 当前已使用的内存（字节）
 	ll        *list.List                    // 双向链表，用于维护访问顺序（链表头为最近访问）
 	cache     map[string]*list.Element      // 哈希表，提供O(1)时间复杂度查找
+	overhead  int64                         // 每个条目额外计入的结构开销（字节）
 	OnEvicted func(key string, value Value) // 可选回调函数，在条目被淘汰时触发
+
+	// OnEvictedReason 可选回调函数，与OnEvicted相同但额外携带淘汰原因
+	// 值被Add覆盖时也会以EvictReplaced触发（OnEvicted不会）
+	OnEvictedReason func(key string, value Value, reason EvictReason)
+}
+
+// EvictReason 条目离开缓存的原因
+type EvictReason int
+
+const (
+	EvictCapacity EvictReason = iota // 超出容量被LRU淘汰（含Resize缩容）
+	EvictExpired                     // 过期后被清理（由调用方通过Evict指定）
+	EvictRemoved                     // 被Remove或Purge显式删除
+	EvictReplaced                    // 值被Add覆盖，回调收到的是旧值
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case EvictCapacity:
+		return "capacity"
+	case EvictExpired:
+		return "expired"
+	case EvictRemoved:
+		return "removed"
+	case EvictReplaced:
+		return "replaced"
+	default:
+		return "unknown"
+	}
 }
 
 // entry 表示缓存中的一个键值对条目
@@ -56,14 +86,18 @@ func (c *Cache) Add(key string, value Value) {
 
 		// 更新内存占用：新值大小 - 旧值大小
 		c.nbytes += int64(value.Len()) - int64(kv.value.Len())
+		old := kv.value
 		kv.value = value
+		if c.OnEvictedReason != nil {
+			c.OnEvictedReason(key, old, EvictReplaced)
+		}
 	} else {
 		// 创建新条目插入链表头部
 		ele := c.ll.PushFront(&entry{key, value})
 		c.cache[key] = ele
 
-		// 增加内存占用：key长度 + value长度
-		c.nbytes += int64(len(key)) + int64(value.Len())
+		// 增加内存占用：key长度 + value长度 + 结构开销
+		c.nbytes += int64(len(key)) + int64(value.Len()) + c.overhead
 	}
 
 	// 内存容量检查与淘汰处理
@@ -91,28 +125,140 @@ func (c *Cache) Get(key string) (value Value, ok bool) {
 	return nil, false
 }
 
+// Peek 获取缓存值但不改变访问顺序
+// 适用于监控、快照与准入策略实验等不应影响LRU顺序的场景
+func (c *Cache) Peek(key string) (value Value, ok bool) {
+	if ele, ok := c.cache[key]; ok {
+		return ele.Value.(*entry).value, true
+	}
+	return nil, false
+}
+
+// Remove 删除指定键的缓存条目
+// 返回值：
+//
+//	ok - 键存在并被删除时为true
+//
+// 与RemoveOldest一致：同步更新内存计数，并触发淘汰回调（如果设置）
+func (c *Cache) Remove(key string) (ok bool) {
+	return c.Evict(key, EvictRemoved)
+}
+
+// Evict 以指定原因删除缓存条目，其余行为与Remove一致
+// 例如按TTL清理过期条目时传入EvictExpired，便于回调区分过期与显式删除
+func (c *Cache) Evict(key string, reason EvictReason) (ok bool) {
+	ele, ok := c.cache[key]
+	if ok {
+		c.removeElement(ele, reason)
+	}
+	return ok
+}
+
 // RemoveOldest 执行LRU淘汰策略
 // 移除链表尾部元素（最久未使用），并同步更新内存计数和哈希表
 func (c *Cache) RemoveOldest() {
 	ele := c.ll.Back() // 获取链表尾部元素
 	if ele != nil {
-		// 从链表中移除
-		c.ll.Remove(ele)
-		kv := ele.Value.(*entry)
+		c.removeElement(ele, EvictCapacity)
+	}
+}
+
+// removeElement 从链表和哈希表中移除元素，并同步更新内存计数
+func (c *Cache) removeElement(ele *list.Element, reason EvictReason) {
+	// 从链表中移除
+	c.ll.Remove(ele)
+	kv := ele.Value.(*entry)
+
+	// 从哈希表删除索引
+	delete(c.cache, kv.key)
 
-		// 从哈希表删除索引
-		delete(c.cache, kv.key)
+	// 更新内存占用
+	c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len()) + c.overhead
 
-		// 更新内存占用
-		c.nbytes -= int64(len(kv.key)) + int64(kv.value.Len())
+	// 触发淘汰回调（如果设置）
+	if c.OnEvicted != nil {
+		c.OnEvicted(kv.key, kv.value)
+	}
+	if c.OnEvictedReason != nil {
+		c.OnEvictedReason(kv.key, kv.value, reason)
+	}
+}
 
-		// 触发淘汰回调（如果设置）
-		if c.OnEvicted != nil {
-			c.OnEvicted(kv.key, kv.value)
+// Resize 调整最大内存容量，超出新容量的条目按LRU顺序淘汰
+// 返回淘汰的条目数；maxBytes为0表示不限制
+func (c *Cache) Resize(maxBytes int64) (evicted int) {
+	c.maxBytes = maxBytes
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveOldest()
+		evicted++
+	}
+	return evicted
+}
+
+// EntryOverhead 估算的每条目结构开销（字节）
+// 包括 list.Element、entry、接口值与map桶中的条目，不含key和value本身
+const EntryOverhead = 120
+
+// SetEntryOverhead 设置每个条目额外计入内存占用的字节数
+// 默认只统计key与value的长度，小值场景下实际内存可能数倍于maxBytes；
+// 设置为 EntryOverhead 可使maxBytes近似约束真实内存。
+// 已有条目按新值重新计数，超出容量的条目按LRU顺序淘汰；n<0 按0处理
+func (c *Cache) SetEntryOverhead(n int64) {
+	if n < 0 {
+		n = 0
+	}
+	c.nbytes += (n - c.overhead) * int64(c.ll.Len())
+	c.overhead = n
+	for c.maxBytes != 0 && c.maxBytes < c.nbytes {
+		c.RemoveOldest()
+	}
+}
+
+// Purge 清空缓存
+// 设置了淘汰回调时按从旧到新的顺序对每个条目调用一次（原因为EvictRemoved）
+func (c *Cache) Purge() {
+	if c.OnEvicted != nil || c.OnEvictedReason != nil {
+		for ele := c.ll.Back(); ele != nil; ele = ele.Prev() {
+			kv := ele.Value.(*entry)
+			if c.OnEvicted != nil {
+				c.OnEvicted(kv.key, kv.value)
+			}
+			if c.OnEvictedReason != nil {
+				c.OnEvictedReason(kv.key, kv.value, EvictRemoved)
+			}
+		}
+	}
+	c.ll.Init()
+	c.cache = make(map[string]*list.Element)
+	c.nbytes = 0
+}
+
+// Range 从链表头部到尾部（最近使用到最久未使用）依次访问条目
+// fn 返回false时停止遍历；遍历不改变访问顺序，fn 中不得修改缓存
+func (c *Cache) Range(fn func(key string, value Value) bool) {
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		kv := ele.Value.(*entry)
+		if !fn(kv.key, kv.value) {
+			return
 		}
 	}
 }
 
+// Keys 返回所有key，顺序与Range一致（最近使用到最久未使用）
+// 返回的是新切片，不改变访问顺序
+func (c *Cache) Keys() []string {
+	keys := make([]string, 0, c.ll.Len())
+	for ele := c.ll.Front(); ele != nil; ele = ele.Next() {
+		keys = append(keys, ele.Value.(*entry).key)
+	}
+	return keys
+}
+
+// Bytes 获取当前已使用的内存（字节）
+func (c *Cache) Bytes() int64 {
+	return c.nbytes
+}
+
 // Len 获取当前缓存条目数量
 // 通过链表长度实现O(1)时间复杂度查询
 func (c *Cache) Len() int {
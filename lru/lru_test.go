@@ -66,3 +66,148 @@ func TestAdd(t *testing.T) {
 		t.Fatal("expected 6 but got", lru.nbytes)
 	}
 }
+
+func TestRemove(t *testing.T) {
+	keys := make([]string, 0)
+	lru := New(int64(0), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.Add("key1", String("1234"))
+
+	if !lru.Remove("key1") || lru.Len() != 0 || lru.nbytes != 0 {
+		t.Fatalf("Remove key1 failed")
+	}
+	if lru.Remove("key1") {
+		t.Fatalf("Remove of missing key should report false")
+	}
+	if !reflect.DeepEqual(keys, []string{"key1"}) {
+		t.Fatalf("expect OnEvicted to be called for key1, got %v", keys)
+	}
+}
+
+func TestPeek(t *testing.T) {
+	k1, k2 := "key1", "key2"
+	lru := New(int64(len(k1+k2+"v1"+"v2")), nil)
+	lru.Add(k1, String("v1"))
+	lru.Add(k2, String("v2"))
+
+	if v, ok := lru.Peek(k1); !ok || string(v.(String)) != "v1" {
+		t.Fatalf("Peek key1 failed")
+	}
+	lru.Add("k3", String("v3")) // Peek未提升key1，key1仍是最久未使用
+	if _, ok := lru.Peek(k1); ok {
+		t.Fatalf("Peek should not promote key1")
+	}
+}
+
+func TestPurge(t *testing.T) {
+	keys := make([]string, 0)
+	lru := New(int64(0), func(key string, value Value) {
+		keys = append(keys, key)
+	})
+	lru.Add("key1", String("1"))
+	lru.Add("key2", String("2"))
+	lru.Purge()
+
+	if lru.Len() != 0 || lru.nbytes != 0 {
+		t.Fatalf("Purge failed")
+	}
+	if !reflect.DeepEqual(keys, []string{"key1", "key2"}) {
+		t.Fatalf("expect OnEvicted from oldest to newest, got %v", keys)
+	}
+	lru.Add("key3", String("3"))
+	if _, ok := lru.Get("key3"); !ok {
+		t.Fatalf("cache unusable after Purge")
+	}
+}
+
+func TestResize(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("key1", String("1"))
+	lru.Add("key2", String("2"))
+	lru.Add("key3", String("3"))
+
+	if n := lru.Resize(int64(len("key3" + "3"))); n != 2 || lru.Len() != 1 {
+		t.Fatalf("expect 2 evictions, got %d", n)
+	}
+	if _, ok := lru.Get("key3"); !ok {
+		t.Fatalf("expect the most recent entry to survive the shrink")
+	}
+	lru.Resize(0)
+	lru.Add("key4", String("4"))
+	if lru.Len() != 2 {
+		t.Fatalf("expect growth after Resize(0), got %d entries", lru.Len())
+	}
+}
+
+func TestOnEvictedReason(t *testing.T) {
+	reasons := make(map[string]EvictReason)
+	lru := New(int64(len("key1"+"1"+"key2"+"2")), nil)
+	lru.OnEvictedReason = func(key string, value Value, reason EvictReason) {
+		reasons[key+"="+string(value.(String))] = reason
+	}
+	lru.Add("key1", String("1"))
+	lru.Add("key1", String("x"))
+	lru.Add("key2", String("2"))
+	lru.Add("key3", String("3")) // 超出容量，淘汰key1
+	lru.Remove("key2")
+	lru.Evict("key3", EvictExpired)
+
+	expect := map[string]EvictReason{
+		"key1=1": EvictReplaced,
+		"key1=x": EvictCapacity,
+		"key2=2": EvictRemoved,
+		"key3=3": EvictExpired,
+	}
+	if !reflect.DeepEqual(reasons, expect) {
+		t.Fatalf("expect reasons %v, got %v", expect, reasons)
+	}
+}
+
+func TestSetEntryOverhead(t *testing.T) {
+	lru := New(int64(100), nil)
+	lru.Add("key1", String("1"))
+	lru.Add("key2", String("2"))
+	if lru.Bytes() != 10 {
+		t.Fatalf("expect 10 bytes without overhead, got %d", lru.Bytes())
+	}
+
+	lru.SetEntryOverhead(40) // 每条目45字节，两条目刚好放下
+	if lru.Bytes() != 90 || lru.Len() != 2 {
+		t.Fatalf("expect 2 entries and 90 bytes, got %d entries and %d bytes", lru.Len(), lru.Bytes())
+	}
+	lru.Add("key3", String("3"))
+	if _, ok := lru.Get("key1"); ok || lru.Len() != 2 {
+		t.Fatalf("expect the overhead to count against maxBytes")
+	}
+	lru.Remove("key2")
+	lru.Remove("key3")
+	if lru.Bytes() != 0 {
+		t.Fatalf("expect removals to release the overhead, got %d bytes", lru.Bytes())
+	}
+}
+
+func TestKeysAndRange(t *testing.T) {
+	lru := New(int64(0), nil)
+	lru.Add("key1", String("1"))
+	lru.Add("key2", String("2"))
+	lru.Add("key3", String("3"))
+	lru.Get("key1")
+
+	expect := []string{"key1", "key3", "key2"}
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("expect keys %v, got %v", expect, keys)
+	}
+
+	var visited []string
+	lru.Range(func(key string, value Value) bool {
+		visited = append(visited, key)
+		return len(visited) < 2
+	})
+	if !reflect.DeepEqual(visited, expect[:2]) {
+		t.Fatalf("expect Range to stop after %v, got %v", expect[:2], visited)
+	}
+	if keys := lru.Keys(); !reflect.DeepEqual(keys, expect) {
+		t.Fatalf("expect iteration not to change the order, got %v", keys)
+	}
+}
@@ -0,0 +1,48 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type maxStaleKey struct{}
+
+// GetWithMaxStale 与GetContext相同，但由调用方声明能容忍的陈旧程度
+// 缓存值写入本节点缓存不超过maxStale时直接返回，否则强制重新获取：
+//   - 本节点负责的key：经singleflight回源并刷新缓存
+//   - 远端节点负责的key：将maxStale随请求发给所属节点，由其按同样规则处理
+//
+// 在Group的TTL之上提供按请求的新鲜度控制；maxStale<=0 表示总是重新获取。
+// 注意：hotCache副本的年龄从其到达本节点时起算，不包含在所属节点上已缓存的时间
+func (g *Group) GetWithMaxStale(ctx context.Context, key string, maxStale time.Duration) (ByteView, error) {
+	v, _, err := g.getWithMaxStale(ctx, key, maxStale)
+	return v, err
+}
+
+// getWithMaxStale GetWithMaxStale的实现，额外返回是否命中缓存
+func (g *Group) getWithMaxStale(ctx context.Context, key string, maxStale time.Duration) (ByteView, bool, error) {
+	if key == "" {
+		return ByteView{}, false, fmt.Errorf("key is required")
+	}
+
+	if g.bypassing() {
+		g.recordGet(key, false)
+		v, err := g.loadBypass(ctx, key)
+		return v, false, err
+	}
+	v, ok := g.lookupCache(key)
+	fresh := ok && time.Since(v.t) <= maxStale
+	g.recordGet(key, fresh)
+	if fresh {
+		return v, true, nil
+	}
+	v, err := g.load(context.WithValue(ctx, maxStaleKey{}, maxStale), key)
+	return v, false, err
+}
+
+// maxStaleFromContext 返回GetWithMaxStale放入ctx的容忍度
+func maxStaleFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(maxStaleKey{}).(time.Duration)
+	return d, ok
+}
@@ -0,0 +1,32 @@
+package geecache
+
+import "time"
+
+// MetricsRecorder 接收Group运行时事件，用于对接外部监控系统（如Prometheus）
+// 设计要点：
+//  1. 事件按Group名称上报，一个Recorder可被多个Group共享
+//  2. 实现必须并发安全，且不应阻塞（在请求路径上同步调用）
+//  3. 未配置时Group不产生任何额外开销
+//
+// 参见 metrics 子包提供的Prometheus实现
+type MetricsRecorder interface {
+	// RecordGet 记录一次Get请求及是否命中本地缓存
+	RecordGet(group string, hit bool)
+	// RecordLoad 记录一次本地回源加载及其结果
+	RecordLoad(group string, err error)
+	// RecordPeerFetch 记录一次远端节点获取的耗时及结果
+	RecordPeerFetch(group string, elapsed time.Duration, err error)
+	// RecordEviction 记录一次缓存条目离开缓存及其原因
+	RecordEviction(group string, reason EvictReason)
+	// TrackBytes 在Group创建时调用一次，bytes用于按需读取当前占用字节数
+	TrackBytes(group string, bytes func() int64)
+	// Untrack 在Group被DestroyGroup注销时调用，应释放TrackBytes登记的函数并停止导出该Group的指标
+	Untrack(group string)
+}
+
+// WithMetrics 为Group配置指标记录器
+func WithMetrics(r MetricsRecorder) GroupOption {
+	return func(g *Group) {
+		g.metrics = r
+	}
+}
@@ -0,0 +1,155 @@
+// Package metrics 提供基于Prometheus的 geecache.MetricsRecorder 实现
+//
+// 典型用法：
+//
+//	rec := metrics.New(nil)
+//	geecache.NewGroup("scores", 2<<10, getter, geecache.WithMetrics(rec))
+//	http.Handle("/metrics", rec.Handler())
+package metrics
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github/lhh-gh/geecache"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "geecache"
+
+// Recorder 按Group维度导出缓存指标
+// 指标列表：
+//   - geecache_gets_total{group,result="hit|miss"}
+//   - geecache_loads_total / geecache_load_errors_total{group}
+//   - geecache_evictions_total{group,reason="capacity|expired|removed|replaced"}
+//   - geecache_bytes{group}（采集时读取）
+//   - geecache_peer_fetches_total{group,result="ok|error"}
+//   - geecache_peer_fetch_seconds{group}
+type Recorder struct {
+	registry *prometheus.Registry
+
+	gets        *prometheus.CounterVec
+	loads       *prometheus.CounterVec
+	loadErrors  *prometheus.CounterVec
+	evictions   *prometheus.CounterVec
+	peerFetches *prometheus.CounterVec
+	peerLatency *prometheus.HistogramVec
+
+	bytesDesc *prometheus.Desc
+	mu        sync.Mutex
+	bytes     map[string]func() int64 // 按Group登记的字节数读取函数
+}
+
+// New 创建Recorder并将指标注册到reg
+// reg 为nil时使用独立的新Registry
+func New(reg *prometheus.Registry) *Recorder {
+	if reg == nil {
+		reg = prometheus.NewRegistry()
+	}
+	r := &Recorder{
+		registry: reg,
+		gets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "gets_total",
+			Help: "Number of Get requests, partitioned by cache hit or miss.",
+		}, []string{"group", "result"}),
+		loads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "loads_total",
+			Help: "Number of loads from the local getter.",
+		}, []string{"group"}),
+		loadErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "load_errors_total",
+			Help: "Number of failed loads from the local getter.",
+		}, []string{"group"}),
+		evictions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "evictions_total",
+			Help: "Number of entries that left the cache, partitioned by reason.",
+		}, []string{"group", "reason"}),
+		peerFetches: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace, Name: "peer_fetches_total",
+			Help: "Number of fetches from remote peers.",
+		}, []string{"group", "result"}),
+		peerLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace, Name: "peer_fetch_seconds",
+			Help:    "Latency of fetches from remote peers.",
+			Buckets: prometheus.ExponentialBuckets(0.0005, 2, 14),
+		}, []string{"group"}),
+		bytesDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "bytes"),
+			"Bytes currently used by the main and hot caches.",
+			[]string{"group"}, nil),
+		bytes: make(map[string]func() int64),
+	}
+	reg.MustRegister(r.gets, r.loads, r.loadErrors, r.evictions, r.peerFetches, r.peerLatency, r)
+	return r
+}
+
+// Handler 返回导出指标的HTTP处理器，可与HTTPPool挂载在同一服务上
+func (r *Recorder) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+}
+
+func (r *Recorder) RecordGet(group string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	r.gets.WithLabelValues(group, result).Inc()
+}
+
+func (r *Recorder) RecordLoad(group string, err error) {
+	r.loads.WithLabelValues(group).Inc()
+	if err != nil {
+		r.loadErrors.WithLabelValues(group).Inc()
+	}
+}
+
+func (r *Recorder) RecordPeerFetch(group string, elapsed time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	r.peerFetches.WithLabelValues(group, result).Inc()
+	r.peerLatency.WithLabelValues(group).Observe(elapsed.Seconds())
+}
+
+func (r *Recorder) RecordEviction(group string, reason geecache.EvictReason) {
+	r.evictions.WithLabelValues(group, reason.String()).Inc()
+}
+
+func (r *Recorder) TrackBytes(group string, bytes func() int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.bytes[group] = bytes
+}
+
+// Untrack 释放group的字节数读取函数（它持有Group的引用），并删除该group的全部指标序列
+func (r *Recorder) Untrack(group string) {
+	r.mu.Lock()
+	delete(r.bytes, group)
+	r.mu.Unlock()
+
+	labels := prometheus.Labels{"group": group}
+	for _, v := range []*prometheus.CounterVec{r.gets, r.loads, r.loadErrors, r.evictions, r.peerFetches} {
+		v.DeletePartialMatch(labels)
+	}
+	r.peerLatency.DeletePartialMatch(labels)
+}
+
+// Describe 实现 prometheus.Collector（仅描述按需采集的字节数指标）
+func (r *Recorder) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.bytesDesc
+}
+
+// Collect 实现 prometheus.Collector，采集时读取各Group当前字节数
+func (r *Recorder) Collect(ch chan<- prometheus.Metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for group, bytes := range r.bytes {
+		ch <- prometheus.MustNewConstMetric(r.bytesDesc, prometheus.GaugeValue, float64(bytes()), group)
+	}
+}
+
+var _ geecache.MetricsRecorder = (*Recorder)(nil)
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github/lhh-gh/geecache"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestRecorder(t *testing.T) {
+	rec := New(nil)
+	g := geecache.NewGroup("metrics", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			if key == "missing" {
+				return nil, fmt.Errorf("%s not exist", key)
+			}
+			return []byte("value"), nil
+		}), geecache.WithMetrics(rec))
+
+	g.Get("k")
+	g.Get("k")
+	g.Get("missing")
+
+	if n := testutil.ToFloat64(rec.gets.WithLabelValues("metrics", "hit")); n != 1 {
+		t.Fatalf("expect 1 hit, got %v", n)
+	}
+	if n := testutil.ToFloat64(rec.gets.WithLabelValues("metrics", "miss")); n != 2 {
+		t.Fatalf("expect 2 misses, got %v", n)
+	}
+	if n := testutil.ToFloat64(rec.loadErrors.WithLabelValues("metrics")); n != 1 {
+		t.Fatalf("expect 1 load error, got %v", n)
+	}
+
+	expected := `
+# HELP geecache_bytes Bytes currently used by the main and hot caches.
+# TYPE geecache_bytes gauge
+geecache_bytes{group="metrics"} 6
+`
+	if err := testutil.CollectAndCompare(rec, strings.NewReader(expected), "geecache_bytes"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := geecache.DestroyGroup("metrics"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(rec); n != 0 {
+		t.Fatalf("expect a destroyed group to stop being exported, got %d series", n)
+	}
+}
@@ -0,0 +1,37 @@
+// Package msgpack provides a MessagePack geecache.Serializer for the peer
+// wire format.
+//
+//	pool := geecache.NewHTTPPool(self, geecache.WithSerializer(msgpack.Serializer{}))
+package msgpack
+
+import (
+	pb "github/lhh-gh/geecache/geecachepb"
+
+	vmsgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+// Serializer encodes peer responses as MessagePack maps.
+type Serializer struct{}
+
+// response is the MessagePack form of geecachepb.Response.
+type response struct {
+	Value []byte `msgpack:"value"`
+}
+
+// ContentType implements geecache.Serializer.
+func (Serializer) ContentType() string { return "application/msgpack" }
+
+// Marshal implements geecache.Serializer.
+func (Serializer) Marshal(res *pb.Response) ([]byte, error) {
+	return vmsgpack.Marshal(&response{Value: res.Value})
+}
+
+// Unmarshal implements geecache.Serializer.
+func (Serializer) Unmarshal(data []byte, res *pb.Response) error {
+	var r response
+	if err := vmsgpack.Unmarshal(data, &r); err != nil {
+		return err
+	}
+	res.Value = r.Value
+	return nil
+}
@@ -0,0 +1,19 @@
+package msgpack
+
+import (
+	"testing"
+
+	pb "github/lhh-gh/geecache/geecachepb"
+)
+
+func TestRoundTrip(t *testing.T) {
+	var s Serializer
+	data, err := s.Marshal(&pb.Response{Value: []byte("value")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res pb.Response
+	if err := s.Unmarshal(data, &res); err != nil || string(res.Value) != "value" {
+		t.Fatalf("expect value, got %q (%v)", res.Value, err)
+	}
+}
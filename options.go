@@ -0,0 +1,120 @@
+package geecache
+
+import (
+	"runtime"
+	"time"
+)
+
+// GroupOption 定义Group的可选配置项（函数式选项模式）
+// 设计目标：在不破坏NewGroup签名的前提下按需扩展配置
+//
+// 典型用法：
+//
+//	NewGroup("events", 1<<20, getter, WithMaxAppendBytes(4<<10))
+type GroupOption func(*Group)
+
+const (
+	// defaultHotCacheRatio hotCache默认容量为mainCache的1/8
+	defaultHotCacheRatio = 8
+	// hotCachePopulateOdds 远端获取的值以1/10的概率写入hotCache
+	hotCachePopulateOdds = 10
+	// defaultNegCacheRatio 负缓存容量为mainCache的1/16
+	defaultNegCacheRatio = 16
+)
+
+// WithHotCacheBytes 设置hotCache的容量（字节）
+// 默认为cacheBytes的1/8，n<=0 时保持默认值
+func WithHotCacheBytes(n int64) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.hotCacheBytes = n
+			g.hotCacheFixed = true
+		}
+	}
+}
+
+// WithEntryOverhead 设置每个缓存条目额外计入容量的结构开销（字节）
+// 默认只统计key与value的长度，大量小值时实际内存远超cacheBytes；
+// 传入 lru.EntryOverhead 可按估算的链表节点与map开销计数，使cacheBytes近似约束真实内存。
+// 作用于mainCache、hotCache与负缓存，n<=0 时保持不计开销
+func WithEntryOverhead(n int64) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.entryOverhead = n
+		}
+	}
+}
+
+// defaultMaxAppendBytes Append操作允许的默认最大值长度
+const defaultMaxAppendBytes = 64 << 10
+
+// WithMaxAppendBytes 设置Append操作后值允许的最大字节数
+// n<=0 时保持默认值
+func WithMaxAppendBytes(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.maxAppendBytes = n
+		}
+	}
+}
+
+// WithShards 将mainCache与hotCache各自拆分为n个分片
+// 分片按key哈希选择，每个分片独立加锁、容量为总容量的1/n，
+// 适用于多核高QPS场景；n<=1 时保持单分片
+func WithShards(n int) GroupOption {
+	return func(g *Group) {
+		if n > 1 {
+			g.shards = n
+		}
+	}
+}
+
+const (
+	// minAutoShardBytes 自动分片时每个分片的最小容量
+	// 分片过小时单个大值就能清空整个分片，淘汰会变得不公平
+	minAutoShardBytes = 4 << 20
+	// maxAutoShards 自动分片的分片数上限
+	maxAutoShards = 256
+)
+
+// WithAutoShards 根据容量与CPU数自动选择分片数
+// 分片数取GOMAXPROCS*4向上取整到2的幂，并保证每个分片不小于4MB、不超过256个分片；
+// 小容量的Group保持单分片。显式的WithShards优先
+func WithAutoShards() GroupOption {
+	return func(g *Group) {
+		if g.shards > 1 {
+			return
+		}
+		g.shards = autoShards(g.cacheBytes, runtime.GOMAXPROCS(0))
+	}
+}
+
+// autoShards 计算cacheBytes容量、procs个CPU时的自动分片数
+func autoShards(cacheBytes int64, procs int) int {
+	n := 1
+	for n < procs*4 && n < maxAutoShards {
+		n <<= 1
+	}
+	for n > 1 && (cacheBytes <= 0 || cacheBytes/int64(n) < minAutoShardBytes) {
+		n >>= 1
+	}
+	return n
+}
+
+// WithNegativeCache 缓存加载失败（如"不存在"）的结果ttl时长
+// 被频繁请求的缺失key在ttl内不会反复回源；写入（Set）或删除（Remove）该key会清除记录。
+// 上下文取消/超时不会被缓存，ttl<=0 表示关闭
+func WithNegativeCache(ttl time.Duration) GroupOption {
+	return func(g *Group) {
+		g.negativeTTL = ttl
+	}
+}
+
+// WithMaxEntryBytes 设置可缓存值的最大字节数
+// 超过n的值照常返回给调用方但不写入缓存，避免单个数MB的对象把整个工作集淘汰出去；
+// n<=0 表示不限制
+func WithMaxEntryBytes(n int) GroupOption {
+	return func(g *Group) {
+		g.maxEntryBytes = n
+	}
+}
@@ -0,0 +1,76 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// OriginProbe 检查数据源是否可用，返回nil表示健康
+type OriginProbe func(ctx context.Context) error
+
+// WithOriginProbe 每隔interval调用probe检查数据源健康状况（每次调用以interval为超时）
+// 数据源被判定为不可用期间：
+//  1. 过期条目不论是否超出WithStaleWhileRevalidate的时长都直接返回旧值（fail-open），不回源
+//  2. 暂停过期后台刷新与ScheduleRefresh，避免向故障中的数据源施压
+//  3. 后台清理任务保留mainCache中的过期条目，留作可返回的旧值
+//
+// 没有旧值的key仍照常回源。下一次探测成功即恢复正常；探测任务随Group.Close停止
+func WithOriginProbe(probe OriginProbe, interval time.Duration) GroupOption {
+	return func(g *Group) {
+		if probe != nil && interval > 0 {
+			g.originProbe, g.probeInterval = probe, interval
+		}
+	}
+}
+
+// HTTPOriginProbe 返回以GET url探测数据源的OriginProbe，2xx响应视为健康
+func HTTPOriginProbe(url string) OriginProbe {
+	return func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return fmt.Errorf("origin probe returned: %v", res.Status)
+		}
+		return nil
+	}
+}
+
+// OriginHealthy 报告最近一次探测时数据源是否健康（未配置WithOriginProbe时总是true）
+func (g *Group) OriginHealthy() bool {
+	return !g.originDown.Load()
+}
+
+// probeOrigin 执行一次探测并更新健康状态，状态变化时记录日志
+func (g *Group) probeOrigin(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, g.probeInterval)
+	defer cancel()
+	err := g.originProbe(ctx)
+	if err != nil && g.bg.closed() {
+		return // Group已关闭，不是数据源的问题
+	}
+	if down := err != nil; g.originDown.Swap(down) != down {
+		if down {
+			g.logger().Warn("origin is unhealthy, serving stale values", "group", g.name, "err", err)
+		} else {
+			g.logger().Info("origin recovered", "group", g.name)
+		}
+	}
+}
+
+// staleWindowNow 返回当前可返回旧值的时长：数据源不可用时不设上限
+func (g *Group) staleWindowNow() time.Duration {
+	if g.originDown.Load() {
+		return math.MaxInt64
+	}
+	return g.staleWindow
+}
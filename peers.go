@@ -1,12 +1,35 @@
 package geecache
 
+import (
+	"context"
+	"time"
+)
+
 // PeerPicker is the interface that must be implemented to locate
 // the peer that owns a specific key.
 type PeerPicker interface {
 	PickPeer(key string) (peer PeerGetter, ok bool)
+	// AllPeers returns every remote peer, excluding this node.
+	AllPeers() []PeerGetter
 }
 
 // PeerGetter is the interface that must be implemented by a peer.
+// The context carries deadlines and cancellation down to the transport.
 type PeerGetter interface {
-	Get(group string, key string) ([]byte, error)
+	Get(ctx context.Context, group string, key string) ([]byte, error)
+	// GetMulti fetches several keys owned by the peer in one round trip.
+	// Keys missing from the result were not found; per-key failures are
+	// reported as a BatchError alongside the values that succeeded.
+	GetMulti(ctx context.Context, group string, keys []string) (map[string][]byte, error)
+	Increment(ctx context.Context, group string, key string, delta int64) (int64, error)
+	Append(ctx context.Context, group string, key string, data []byte) (int, error)
+	CAS(ctx context.Context, group string, key string, old, new []byte) (bool, error)
+	Remove(ctx context.Context, group string, key string) error
+}
+
+// ReplayRemover is optionally implemented by a PeerGetter whose peer can
+// schedule the second delete of Group.DeleteWithReplay itself, so that the
+// replay survives the caller going away.
+type ReplayRemover interface {
+	RemoveWithReplay(ctx context.Context, group string, key string, delay time.Duration) error
 }
@@ -0,0 +1,41 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// defaultPeerTimeout 单次远端获取的默认超时
+const defaultPeerTimeout = 500 * time.Millisecond
+
+// ErrPeerTimeout 远端获取在WithPeerTimeout内未完成
+// 与调用方ctx到期不同，它被视为瞬时失败：按WithPeerRetries重试，
+// 并在WithPeerFallback下改由本节点回源
+var ErrPeerTimeout = errors.New("geecache: peer fetch timed out")
+
+// WithPeerTimeout 设置单次远端获取（每次重试单独计时）的超时，默认500ms
+// 避免一个慢节点拖住Get与GetMulti；d<=0 表示只受调用方ctx约束
+func WithPeerTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		if d < 0 {
+			d = 0
+		}
+		g.peerTimeout = d
+	}
+}
+
+// withPeerTimeout 在peerTimeout内调用fetch，超时返回包装了ErrPeerTimeout的错误
+func (g *Group) withPeerTimeout(ctx context.Context, fetch func(ctx context.Context) ([]byte, error)) ([]byte, error) {
+	if g.peerTimeout <= 0 {
+		return fetch(ctx)
+	}
+	tctx, cancel := context.WithTimeout(ctx, g.peerTimeout)
+	defer cancel()
+	b, err := fetch(tctx)
+	if err != nil && ctx.Err() == nil && errors.Is(tctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %v: %w", ErrPeerTimeout, g.peerTimeout, err)
+	}
+	return b, err
+}
@@ -0,0 +1,163 @@
+package geecache
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// 持久化格式（流式、长度前缀，大端序）：
+//
+//	magic   "geecache1"
+//	条目*   keyLen uint32 | key | expire int64（UnixNano，0表示永不过期）| valueLen uint32 | value
+//
+// 文件不包含条目数，读到EOF即结束
+const persistMagic = "geecache1"
+
+// writeEntries 将条目写入w
+func writeEntries(w io.Writer, entries []snapshotEntry) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(persistMagic); err != nil {
+		return err
+	}
+	var hdr [8]byte
+	for _, e := range entries {
+		binary.BigEndian.PutUint32(hdr[:4], uint32(len(e.key)))
+		bw.Write(hdr[:4])
+		bw.WriteString(e.key)
+		var expire int64
+		if !e.value.e.IsZero() {
+			expire = e.value.e.UnixNano()
+		}
+		binary.BigEndian.PutUint64(hdr[:8], uint64(expire))
+		bw.Write(hdr[:8])
+		binary.BigEndian.PutUint32(hdr[:4], uint32(e.value.Len()))
+		bw.Write(hdr[:4])
+		if _, err := bw.Write(e.value.b); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// readEntries 从r读取条目，对每个未过期的条目调用fn
+func readEntries(r io.Reader, fn func(key string, value ByteView)) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(persistMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	if string(magic) != persistMagic {
+		return errors.New("geecache: not a geecache snapshot")
+	}
+
+	now := time.Now()
+	var hdr [8]byte
+	for {
+		if _, err := io.ReadFull(br, hdr[:4]); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("reading entry: %w", err)
+		}
+		key := make([]byte, binary.BigEndian.Uint32(hdr[:4]))
+		if _, err := io.ReadFull(br, key); err != nil {
+			return fmt.Errorf("reading key: %w", err)
+		}
+		if _, err := io.ReadFull(br, hdr[:8]); err != nil {
+			return fmt.Errorf("reading expiry of %s: %w", key, err)
+		}
+		var value ByteView
+		if expire := int64(binary.BigEndian.Uint64(hdr[:8])); expire != 0 {
+			value.e = time.Unix(0, expire)
+		}
+		if _, err := io.ReadFull(br, hdr[:4]); err != nil {
+			return fmt.Errorf("reading value of %s: %w", key, err)
+		}
+		value.b = make([]byte, binary.BigEndian.Uint32(hdr[:4]))
+		if _, err := io.ReadFull(br, value.b); err != nil {
+			return fmt.Errorf("reading value of %s: %w", key, err)
+		}
+		if !value.expired(now) {
+			fn(string(key), value)
+		}
+	}
+}
+
+// SaveSnapshot 将本节点mainCache中未过期的条目写入w，用于重启后预热
+// 条目按从最久未使用到最近使用的顺序写出，LoadSnapshot后访问顺序得以保留；
+// hotCache只是其他节点数据的副本，不写入快照
+func (g *Group) SaveSnapshot(w io.Writer) error {
+	entries := g.mainCache.appendEntries(nil, time.Now())
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return writeEntries(w, entries)
+}
+
+// LoadSnapshot 从r恢复SaveSnapshot写出的条目，保留各条目的过期时间，来源记为SourceSnapshot
+// 已过期或超过长度上限的条目被忽略；读取出错时已恢复的条目保留
+func (g *Group) LoadSnapshot(r io.Reader) error {
+	return readEntries(r, func(key string, value ByteView) {
+		value.src = SourceSnapshot
+		g.populateEncoded(key, value)
+	})
+}
+
+// SaveNegativeCache 将负缓存（近期确认不存在或加载失败的key）写入w
+func (g *Group) SaveNegativeCache(w io.Writer) error {
+	return writeEntries(w, g.negCache.appendEntries(nil, time.Now()))
+}
+
+// LoadNegativeCache 从w恢复负缓存，已过期的记录被忽略
+// 重启后的节点因此不会对已知不存在的key再次回源
+func (g *Group) LoadNegativeCache(r io.Reader) error {
+	return readEntries(r, func(key string, value ByteView) {
+		g.negCache.add(key, value)
+	})
+}
+
+// WithNegativeCacheFile 在重启之间持久化负缓存
+// NewGroup时从path恢复（文件不存在时忽略），Group.Close时写回
+func WithNegativeCacheFile(path string) GroupOption {
+	return func(g *Group) {
+		g.negCacheFile = path
+	}
+}
+
+// loadNegativeCacheFile 从配置的文件恢复负缓存
+func (g *Group) loadNegativeCacheFile() {
+	f, err := os.Open(g.negCacheFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			g.logger().Warn("loading negative cache", "group", g.name, "err", err)
+		}
+		return
+	}
+	defer f.Close()
+	if err := g.LoadNegativeCache(f); err != nil {
+		g.logger().Warn("loading negative cache", "group", g.name, "err", err)
+	}
+}
+
+// saveNegativeCacheFile 将负缓存写入配置的文件（先写临时文件再改名）
+func (g *Group) saveNegativeCacheFile() error {
+	tmp := g.negCacheFile + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := g.SaveNegativeCache(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, g.negCacheFile)
+}
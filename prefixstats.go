@@ -0,0 +1,86 @@
+package geecache
+
+import (
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// PrefixStats 一个key前缀分区的统计信息
+// 用途：多个业务共用一个Group时，查看哪个业务占用了容量、哪个获得了命中
+type PrefixStats struct {
+	Gets  int64 // 该前缀下key的Get请求数
+	Hits  int64 // 命中次数
+	Bytes int64 // 当前占用字节数（key与存储值长度之和加WithEntryOverhead的每条目开销，与LRU计算方式一致）
+	Items int64 // 当前条目数（含已过期但尚未清除的条目）
+}
+
+// prefixCounters 一个前缀分区的请求计数器
+type prefixCounters struct {
+	gets, hits atomic.Int64
+}
+
+// WithPrefixStats 按key前缀分区统计
+// 每个key归入匹配的最长前缀，不匹配任何前缀的key归入""分区；
+// 通过 Group.PrefixStats 读取
+func WithPrefixStats(prefixes ...string) GroupOption {
+	return func(g *Group) {
+		ps := append([]string(nil), prefixes...)
+		sort.Slice(ps, func(i, j int) bool { return len(ps[i]) > len(ps[j]) })
+		g.prefixes = ps
+		g.prefixCounters = make(map[string]*prefixCounters, len(ps)+1)
+		g.prefixCounters[""] = &prefixCounters{}
+		for _, p := range ps {
+			g.prefixCounters[p] = &prefixCounters{}
+		}
+	}
+}
+
+// prefixOf 返回key所属的前缀分区
+func (g *Group) prefixOf(key string) string {
+	for _, p := range g.prefixes {
+		if strings.HasPrefix(key, p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// recordPrefixGet 记录一次Get到key所属分区（未启用分区统计时为空操作）
+func (g *Group) recordPrefixGet(key string, hit bool) {
+	if g.prefixCounters == nil {
+		return
+	}
+	c := g.prefixCounters[g.prefixOf(key)]
+	c.gets.Add(1)
+	if hit {
+		c.hits.Add(1)
+	}
+}
+
+// PrefixStats 返回各前缀分区的统计信息，未启用时返回nil
+// 占用字节与条目数通过遍历mainCache与hotCache中存储的条目计算：值按编码后的长度计，
+// 已过期未清除的条目与两个缓存中的重复副本都计入，各分区之和等于CacheStats的Bytes与Items。
+// 开销与条目数成正比，适合按分钟级频率采集，不适合放在请求路径上
+func (g *Group) PrefixStats() map[string]PrefixStats {
+	if g.prefixCounters == nil {
+		return nil
+	}
+	out := make(map[string]PrefixStats, len(g.prefixCounters))
+	for p, c := range g.prefixCounters {
+		out[p] = PrefixStats{Gets: c.gets.Load(), Hits: c.hits.Load()}
+	}
+	var entries []snapshotEntry
+	for _, c := range []*shardedCache{g.mainCache, g.hotCache} {
+		entries = c.appendEntries(entries, time.Time{}) // 零时刻之前没有条目过期，即全部计入
+	}
+	for _, e := range entries {
+		p := g.prefixOf(e.key)
+		s := out[p]
+		s.Bytes += int64(len(e.key)+e.value.Len()) + g.entryOverhead
+		s.Items++
+		out[p] = s
+	}
+	return out
+}
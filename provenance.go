@@ -0,0 +1,60 @@
+package geecache
+
+import (
+	"context"
+	"time"
+)
+
+// Source 记录缓存条目是如何进入本节点缓存的
+// 用途：排查多路径写入的分布式缓存中出现的意外值
+type Source uint8
+
+const (
+	SourceUnknown  Source = iota // 未记录来源
+	SourceLoad                   // 本节点Getter回源加载
+	SourcePeer                   // 从所属节点获取（hotCache中的副本）
+	SourceSet                    // 显式写入：Set/SetWithTTL及Increment/Append/CAS
+	SourceL2                     // 从第二级缓存读取（见WithL2）
+	SourceFallback               // 所属节点不可达时由本节点回源的非权威副本（见WithPeerFallback）
+	SourceSnapshot               // 从快照恢复（见LoadSnapshot）
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceLoad:
+		return "load"
+	case SourcePeer:
+		return "peer"
+	case SourceSet:
+		return "set"
+	case SourceL2:
+		return "l2"
+	case SourceFallback:
+		return "fallback"
+	case SourceSnapshot:
+		return "snapshot"
+	default:
+		return "unknown"
+	}
+}
+
+// Source 返回值的来源
+func (v ByteView) Source() Source {
+	return v.src
+}
+
+// EntryInfo 描述GetWithInfo返回的值
+type EntryInfo struct {
+	Source Source    // 值的来源
+	Expire time.Time // 过期时间（零值表示永不过期）
+	Hit    bool      // 是否命中本节点缓存（false表示本次调用加载）
+}
+
+// GetWithInfo 与GetContext相同，额外返回值的来源与缓存状态
+func (g *Group) GetWithInfo(ctx context.Context, key string) (ByteView, EntryInfo, error) {
+	v, hit, err := g.get(ctx, key)
+	if err != nil {
+		return ByteView{}, EntryInfo{}, err
+	}
+	return v, EntryInfo{Source: v.src, Expire: v.e, Hit: hit}, nil
+}
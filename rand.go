@@ -0,0 +1,58 @@
+package geecache
+
+import (
+	"math/rand"
+	"sync"
+)
+
+// randSource Group内部使用的随机数来源
+// 所有随机行为（热点缓存概率写入、重试抖动等）都经由它获取随机数，
+// 注入固定种子即可让测试与问题复现具有确定性
+type randSource interface {
+	Intn(n int) int
+	Int63n(n int64) int64
+	Float64() float64
+}
+
+// globalRand 默认实现，委托给 math/rand 的全局（并发安全）函数
+type globalRand struct{}
+
+func (globalRand) Intn(n int) int       { return rand.Intn(n) }
+func (globalRand) Int63n(n int64) int64 { return rand.Int63n(n) }
+func (globalRand) Float64() float64     { return rand.Float64() }
+
+// lockedRand 为 *rand.Rand 加锁（rand.Rand 本身非并发安全）
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+func (l *lockedRand) Intn(n int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Intn(n)
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+// WithRandSource 为Group注入随机数来源
+// 典型用法（测试中固定种子）：
+//
+//	NewGroup("scores", 2<<10, getter, WithRandSource(rand.NewSource(42)))
+func WithRandSource(src rand.Source) GroupOption {
+	return func(g *Group) {
+		if src != nil {
+			g.rand = &lockedRand{r: rand.New(src)}
+		}
+	}
+}
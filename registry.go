@@ -0,0 +1,213 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"github/lhh-gh/geecache/singleflight"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// Registry 缓存组注册表：一组按名称相互可见的Group
+// 包级函数（NewGroup、GetGroup等）操作默认注册表；需要在同一进程中运行
+// 多个相互独立的缓存（如测试、多租户服务）时各自创建Registry，
+// 并通过WithRegistry让HTTPPool只服务其中一个。
+// 进程级的回源预算（SetOriginBudget）仍由所有注册表共享
+type Registry struct {
+	mu     sync.Mutex                        // 串行化groups的更新
+	groups atomic.Pointer[map[string]*Group] // 缓存组注册表（写时复制，读取无锁）
+}
+
+// WithRegistry 让HTTPPool服务r中的Group，而不是默认注册表中的
+func WithRegistry(r *Registry) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if r != nil {
+			p.registry = r
+		}
+	}
+}
+
+// defaultRegistry 包级函数使用的注册表
+var defaultRegistry = NewRegistry()
+
+// NewRegistry 创建一个空的注册表
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// NewGroup 创建缓存组并注册到r，参数见包级函数NewGroup
+// 同名的旧Group被替换并按DestroyGroup的方式清理，清理在新Group加载负缓存文件之前完成
+func (r *Registry) NewGroup(name string, cacheBytes int64, getter Getter, opts ...GroupOption) *Group {
+	if getter == nil {
+		panic("nil Getter") // 严格校验防止错误配置
+	}
+	if cacheBytes < 0 {
+		panic(fmt.Sprintf("geecache: negative cacheBytes %d for group %s", cacheBytes, name))
+	}
+
+	cg, ok := getter.(ContextGetter)
+	if !ok {
+		cg = getterAdapter{getter}
+	}
+
+	bg, _ := getter.(BatchGetter)
+
+	g := &Group{
+		name:        name,
+		getter:      cg,
+		batchGetter: bg,
+		loader:      &singleflight.Group{},
+
+		cacheBytes:     cacheBytes,
+		hotCacheBytes:  cacheBytes / defaultHotCacheRatio,
+		shards:         1,
+		maxAppendBytes: defaultMaxAppendBytes,
+		peerTimeout:    defaultPeerTimeout,
+		rand:           globalRand{},
+
+		backgroundLimit: defaultBackgroundLimit,
+		originWeight:    defaultOriginWeight,
+		originLimiter:   rate.NewLimiter(rate.Inf, 1),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if kp, ok := getter.(KeyPolicy); ok && g.keyPolicy == nil {
+		g.keyPolicy = kp
+	}
+	// 先停止同名旧Group：Close写回它的负缓存文件，新Group随后加载到的是最新内容，
+	// 旧Group也不会在之后覆盖新Group的文件
+	prev := r.GetGroup(name)
+	if prev != nil {
+		prev.retire(true)
+	}
+	// 选项确定容量与分片数后再创建缓存（LRU仍延迟创建）
+	mainEvicted, hotEvicted := g.evictionHandlers()
+	g.mainCache = newShardedCache(g.cacheBytes, g.shards, mainEvicted)
+	g.hotCache = newShardedCache(g.hotCacheBytes, g.shards, hotEvicted)
+	g.negCache = newShardedCache(g.cacheBytes/defaultNegCacheRatio, g.shards, nil)
+	if g.entryOverhead > 0 {
+		for _, c := range []*shardedCache{g.mainCache, g.hotCache, g.negCache} {
+			c.setEntryOverhead(g.entryOverhead)
+		}
+	}
+	if g.negCacheFile != "" {
+		g.loadNegativeCacheFile()
+	}
+	g.bg = newBackground(g.backgroundLimit)
+	if g.janitorInterval > 0 {
+		g.bg.loop(g.janitorInterval, func(context.Context) { g.sweepExpired() })
+	}
+	if g.originProbe != nil {
+		g.bg.loop(g.probeInterval, g.probeOrigin)
+	}
+	if g.metrics != nil {
+		g.metrics.TrackBytes(name, func() int64 {
+			return g.mainCache.bytes() + g.hotCache.bytes()
+		})
+	}
+	r.mu.Lock()
+	old := r.snapshot()[name]
+	r.register(g)
+	origins.add(g, g.originWeight)
+	r.mu.Unlock()
+
+	if old != nil && old != prev {
+		old.retire(false) // 并发创建的同名Group，不写回负缓存文件以免覆盖g的
+	}
+	return g
+}
+
+// GetGroup 按名称查找已注册的缓存组（安全并发读）
+// 性能优化：每个节点请求都要查找Group，而注册表几乎只在启动时变化，
+// 因此读取一个原子发布的快照，不获取任何锁
+func (r *Registry) GetGroup(name string) *Group {
+	return r.snapshot()[name]
+}
+
+// ListGroups 返回所有已注册缓存组的名称（按字典序）
+func (r *Registry) ListGroups() []string {
+	gs := r.snapshot()
+	names := make([]string, 0, len(gs))
+	for name := range gs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DestroyGroup 注销并清空名为name的缓存组，name不存在时什么也不做
+// 用途：按动态命名空间创建Group的常驻进程在命名空间下线时释放内存
+// 注销后：
+//   - GetGroup与节点请求都找不到它，同名Group可以重新创建
+//   - 后台任务停止（见Close，返回值为其错误），不再占用回源预算份额
+//   - 配置的MetricsRecorder收到Untrack，不再导出该Group的指标
+//   - mainCache、hotCache与负缓存被清空；L2可能由多个节点共享，不清空
+//
+// 仍持有*Group的调用方可以继续使用它，但它已脱离注册表与节点间的路由
+func (r *Registry) DestroyGroup(name string) error {
+	r.mu.Lock()
+	g := r.snapshot()[name]
+	if g != nil {
+		r.unregister(name)
+	}
+	r.mu.Unlock()
+	if g == nil {
+		return nil
+	}
+
+	if g.metrics != nil {
+		g.metrics.Untrack(name) // 同名替换时新Group已重新登记，只在注销时调用
+	}
+	return g.retire(true)
+}
+
+// retire 停止g的后台任务、交还回源预算份额并清空其缓存（L2除外）
+// save为true时与Close相同，写回负缓存文件并返回其错误
+func (g *Group) retire(save bool) error {
+	origins.remove(g)
+	var err error
+	if save {
+		err = g.Close()
+	} else {
+		g.bg.close()
+	}
+	g.mainCache.clear()
+	g.hotCache.clear()
+	g.negCache.clear()
+	return err
+}
+
+// snapshot 返回注册表的当前快照，调用方不得修改
+func (r *Registry) snapshot() map[string]*Group {
+	if m := r.groups.Load(); m != nil {
+		return *m
+	}
+	return nil
+}
+
+// register 复制注册表、加入g后原子替换（调用方需持有mu）
+func (r *Registry) register(g *Group) {
+	old := r.snapshot()
+	m := make(map[string]*Group, len(old)+1)
+	for name, og := range old {
+		m[name] = og
+	}
+	m[g.name] = g
+	r.groups.Store(&m)
+}
+
+// unregister 复制注册表、删除name后原子替换（调用方需持有mu）
+func (r *Registry) unregister(name string) {
+	old := r.snapshot()
+	m := make(map[string]*Group, len(old))
+	for n, og := range old {
+		if n != name {
+			m[n] = og
+		}
+	}
+	r.groups.Store(&m)
+}
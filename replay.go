@@ -0,0 +1,64 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// maxReplayDelay 第二次删除的最长延迟，更长的延迟通常是配置错误，且会长期占用定时器
+const maxReplayDelay = time.Hour
+
+// validReplayDelay 校验延迟双删的delay，须在(0, maxReplayDelay]之内
+func validReplayDelay(delay time.Duration) error {
+	if delay <= 0 || delay > maxReplayDelay {
+		return fmt.Errorf("replay delay %v out of range (0, %v]", delay, maxReplayDelay)
+	}
+	return nil
+}
+
+// DeleteWithReplay 实现延迟双删：立即在集群内删除key，并在delay后再删除一次
+// 解决的竞态：应用更新数据库后删除缓存，但一个并发读在删除之后、
+// 数据库提交之前回源，把旧值重新写回缓存；第二次删除清除这个旧值。
+// delay应大于一次回源加载的耗时。
+//
+// 第二次删除由每个节点各自调度（通过ReplayRemover通知远端节点），
+// 调用方节点在delay内退出也不影响其他节点；不支持ReplayRemover的远端节点
+// 由本节点在delay后再次发送删除。Group关闭后不再执行已调度的删除。
+// delay须在(0, maxReplayDelay]之内，否则不删除并返回错误
+func (g *Group) DeleteWithReplay(key string, delay time.Duration) error {
+	if key == "" {
+		return fmt.Errorf("key is required")
+	}
+	if err := validReplayDelay(delay); err != nil {
+		return err
+	}
+	err := g.removeEverywhere(key, func(ctx context.Context, peer PeerGetter) error {
+		if r, ok := peer.(ReplayRemover); ok {
+			return r.RemoveWithReplay(ctx, g.name, key, delay)
+		}
+		if err := peer.Remove(ctx, g.name, key); err != nil {
+			return err
+		}
+		time.AfterFunc(delay, func() {
+			if !g.bg.closed() {
+				peer.Remove(context.Background(), g.name, key)
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	g.scheduleReplay(key, delay)
+	return nil
+}
+
+// scheduleReplay 在delay后再次删除本节点的副本
+func (g *Group) scheduleReplay(key string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		if !g.bg.closed() {
+			g.removeLocally(key)
+		}
+	})
+}
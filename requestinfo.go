@@ -0,0 +1,61 @@
+package geecache
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestInfo 描述发起一次缓存读取的调用方
+// 用途：ContextGetter 据此按租户路由数据源、记录审计日志
+//
+// 传递方式：
+//   - 应用通过 WithRequestInfo 放入 ctx 后调用 GetContext
+//   - key属于远端节点时，Tenant与TraceID随节点间请求一起发送
+//   - 节点服务端把请求头还原为RequestInfo，并填入发起请求的节点名（Peer）
+type RequestInfo struct {
+	Peer    string // 转发请求的节点（本地发起的请求为空）
+	Tenant  string // 租户/调用主体
+	TraceID string // 链路追踪ID
+}
+
+// 节点间传递RequestInfo的请求头（Peer使用ring.go中的peerHeader）
+const (
+	tenantHeader  = "X-Geecache-Tenant"
+	traceIDHeader = "X-Geecache-Trace-Id"
+)
+
+type requestInfoKey struct{}
+
+// WithRequestInfo 返回携带info的ctx
+func WithRequestInfo(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+// RequestInfoFromContext 取出ctx中的RequestInfo
+func RequestInfoFromContext(ctx context.Context) (RequestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info, ok
+}
+
+// setRequestInfoHeaders 将ctx中的租户与追踪ID写入节点间请求
+func setRequestInfoHeaders(ctx context.Context, h http.Header) {
+	info, ok := RequestInfoFromContext(ctx)
+	if !ok {
+		return
+	}
+	if info.Tenant != "" {
+		h.Set(tenantHeader, info.Tenant)
+	}
+	if info.TraceID != "" {
+		h.Set(traceIDHeader, info.TraceID)
+	}
+}
+
+// requestInfoFromHeaders 由节点间请求头还原RequestInfo
+func requestInfoFromHeaders(h http.Header) RequestInfo {
+	return RequestInfo{
+		Peer:    h.Get(peerHeader),
+		Tenant:  h.Get(tenantHeader),
+		TraceID: h.Get(traceIDHeader),
+	}
+}
@@ -0,0 +1,57 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// WithPeerRetries 远端获取出现瞬时失败时最多重试n次
+// 瞬时失败指连接错误、WithPeerTimeout超时或502/503/504；500表示所属节点自身回源失败，不重试。
+// 第i次重试前随机等待[backoff·2^i/2, backoff·2^i)，避免从同一次抖动中恢复的节点同步重试；
+// n<=0 或 backoff<=0 时不重试
+func WithPeerRetries(n int, backoff time.Duration) GroupOption {
+	return func(g *Group) {
+		if n > 0 && backoff > 0 {
+			g.peerRetries, g.peerBackoff = n, backoff
+		}
+	}
+}
+
+// retryable 判断远端获取的错误是否值得重试
+func retryable(err error) bool {
+	if errors.Is(err, ErrPeerTimeout) {
+		return true
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code == http.StatusBadGateway || se.code == http.StatusServiceUnavailable ||
+			se.code == http.StatusGatewayTimeout
+	}
+	var oe *net.OpError
+	return errors.As(err, &oe)
+}
+
+// withPeerRetries 调用fetch，瞬时失败时最多重试retries次，直到成功、次数用尽或ctx结束
+func (g *Group) withPeerRetries(ctx context.Context, retries int, backoff time.Duration, fetch func() ([]byte, error)) ([]byte, error) {
+	b, err := fetch()
+	for i := 0; i < retries && err != nil && retryable(err); i++ {
+		d := backoff << i
+		d = d/2 + time.Duration(g.rand.Int63n(int64(d/2)+1))
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		}
+		g.stats.peerRetries.Add(1)
+		b, err = fetch()
+	}
+	return b, err
+}
@@ -0,0 +1,140 @@
+package geecache
+
+import (
+	"fmt"
+	"github/lhh-gh/geecache/consistenthash"
+	"hash/fnv"
+	"net/http"
+	"sort"
+)
+
+// Every peer request carries the sender's name and a hash of its ring
+// membership. A receiver whose own ring hashes differently is routing keys
+// differently from the sender, which usually means discovery is
+// misconfigured: both nodes then believe they own some keys and load them
+// from the origin twice.
+const (
+	peerHeader = "X-Geecache-Peer"
+	ringHeader = "X-Geecache-Ring"
+
+	// defaultSplitBrainThreshold is the number of consecutive disagreeing
+	// requests from one peer after which a split brain is reported.
+	defaultSplitBrainThreshold = 3
+)
+
+// SplitBrainFunc is called when requests from peer have persistently
+// carried a ring hash different from the local one.
+type SplitBrainFunc func(peer, peerRing, localRing string)
+
+// WithSplitBrainDetection reports a split brain to fn once threshold
+// consecutive requests from the same peer disagree about the ring.
+// fn is called again only after the peer has agreed in between.
+func WithSplitBrainDetection(threshold int, fn SplitBrainFunc) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if threshold > 0 {
+			p.splitThreshold = threshold
+		}
+		p.onSplitBrain = fn
+	}
+}
+
+// WithStrictOwnership refuses owner-arbitrated mutations (increment,
+// append, compare-and-swap) with 421 Misdirected Request while the sender
+// disagrees about the ring, instead of applying them on a node that may
+// not be the owner the rest of the cluster sees.
+func WithStrictOwnership() HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.strictOwnership = true
+	}
+}
+
+// ringHash identifies a ring membership independently of peer order.
+func ringHash(peers []string) string {
+	sorted := append([]string(nil), peers...)
+	sort.Strings(sorted)
+	h := fnv.New64a()
+	for _, peer := range sorted {
+		h.Write([]byte(peer))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// peerRing is an immutable snapshot of the pool's membership. Routing
+// reads it on every request without locking; membership changes build a
+// new one and swap it in.
+type peerRing struct {
+	peers   *consistenthash.Map
+	getters map[string]*httpGetter // keyed by e.g. "http://10.0.0.2:8008"
+	hash    string                 // see ringHash
+}
+
+// emptyRing is the membership of a pool whose peers were never set.
+var emptyRing = newPeerRing(nil)
+
+// newPeerRing builds the snapshot for getters, which it takes ownership of.
+func newPeerRing(getters map[string]*httpGetter) *peerRing {
+	names := make([]string, 0, len(getters))
+	for peer := range getters {
+		names = append(names, peer)
+	}
+	peers := consistenthash.New(defaultReplicas, nil)
+	peers.Add(names...)
+	return &peerRing{peers: peers, getters: getters, hash: ringHash(names)}
+}
+
+// ring returns the current membership snapshot.
+func (p *HTTPPool) ring() *peerRing {
+	if r := p.state.Load(); r != nil {
+		return r
+	}
+	return emptyRing
+}
+
+// getter returns the getter of peer, or nil if it is not a member.
+func (p *HTTPPool) getter(peer string) *httpGetter {
+	return p.ring().getters[peer]
+}
+
+// RingHash returns the hash of the current ring membership.
+func (p *HTTPPool) RingHash() string {
+	return p.ring().hash
+}
+
+// RingMismatches returns the number of peer requests received whose
+// sender disagreed about the ring.
+func (p *HTTPPool) RingMismatches() int64 {
+	return p.ringMismatches.Load()
+}
+
+// checkRing compares the sender's ring with ours and reports whether they
+// agree. Requests without ring information, e.g. from older peers, agree.
+func (p *HTTPPool) checkRing(r *http.Request) bool {
+	peer, theirs := r.Header.Get(peerHeader), r.Header.Get(ringHeader)
+	if theirs == "" {
+		return true
+	}
+
+	ours := p.RingHash()
+	p.mu.Lock()
+	if ours == "" || theirs == ours {
+		delete(p.ringStreaks, peer)
+		p.mu.Unlock()
+		return true
+	}
+	if p.ringStreaks == nil {
+		p.ringStreaks = make(map[string]int)
+	}
+	p.ringStreaks[peer]++
+	report := p.ringStreaks[peer] == p.splitThreshold
+	p.mu.Unlock()
+
+	p.ringMismatches.Add(1)
+	if report {
+		p.logger().Warn("split brain", "self", p.self, "peer", peer, "peer_ring", theirs, "ring", ours)
+		if p.onSplitBrain != nil {
+			p.onSplitBrain(peer, theirs, ours)
+		}
+	}
+	return false
+}
@@ -0,0 +1,27 @@
+package geecache
+
+// RoutingKeyFunc 由缓存key推导用于节点路由（一致性哈希）的key
+type RoutingKeyFunc func(key string) string
+
+// WithRoutingKey 让fn(key)而不是key决定key的所属节点
+// 典型用法：同一用户的key（如"user:42:profile"、"user:42:orders"）路由到同一节点，
+// 批量获取一次往返即可取回，而它们仍是各自独立的缓存条目。
+// 所有节点必须为同名Group配置相同的fn，否则节点间对所属节点的判断不一致
+func WithRoutingKey(fn RoutingKeyFunc) GroupOption {
+	return func(g *Group) {
+		g.routingKeyFn = fn
+	}
+}
+
+// routingKey 返回key用于节点路由的key
+func (g *Group) routingKey(key string) string {
+	if g.routingKeyFn == nil {
+		return key
+	}
+	return g.routingKeyFn(key)
+}
+
+// pickPeer 按路由key选择key的所属节点，调用方需保证g.peers不为nil
+func (g *Group) pickPeer(key string) (PeerGetter, bool) {
+	return g.peers.PickPeer(g.routingKey(key))
+}
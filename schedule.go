@@ -0,0 +1,101 @@
+package geecache
+
+import (
+	"context"
+	"path"
+	"time"
+)
+
+// ScheduleRefresh 每隔interval从数据源重新加载keys，与是否被访问无关
+// 适用于必须始终新鲜且常驻缓存的数据（汇率、功能开关等）。
+// 行为：
+//  1. 调度时立即刷新一次，之后按interval周期执行
+//  2. 只刷新本节点负责的key，其他节点的key由其所属节点各自调度
+//  3. 刷新失败时保留旧值并记录日志，下个周期重试
+//
+// 返回的stop函数停止该调度；Group.Close会停止所有调度。interval<=0 时不调度
+func (g *Group) ScheduleRefresh(keys []string, interval time.Duration) (stop func()) {
+	keys = append([]string(nil), keys...)
+	return g.schedule(interval, func() []string { return keys })
+}
+
+// ScheduleRefreshMatching 与ScheduleRefresh相同，刷新的是mainCache中
+// 名称匹配pattern（path.Match语法）的key
+// 每个周期重新匹配，因此新加载的key会自动纳入；已被淘汰的key不再刷新
+func (g *Group) ScheduleRefreshMatching(pattern string, interval time.Duration) (stop func()) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		g.logger().Error("bad refresh pattern", "group", g.name, "pattern", pattern, "err", err)
+		return func() {}
+	}
+	return g.schedule(interval, func() []string {
+		var keys []string
+		for _, key := range g.mainCache.keys() {
+			if ok, _ := path.Match(pattern, key); ok {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	})
+}
+
+// schedule 启动周期刷新任务，keys在每个周期调用一次
+func (g *Group) schedule(interval time.Duration, keys func() []string) (stop func()) {
+	if interval <= 0 || g.bg.closed() {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(g.bg.ctx)
+	run := func(ctx context.Context) {
+		for _, key := range keys() {
+			if ctx.Err() != nil {
+				return
+			}
+			g.reload(ctx, key)
+		}
+	}
+	g.bg.start(func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			run(ctx)
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	})
+	return cancel
+}
+
+// reload 绕过缓存从数据源重新加载本节点负责的key并回填mainCache
+// 直接调用Getter：不经过负缓存与L2，否则刷新的只是缓存中的旧值，不存在的key也永远不会被刷新
+func (g *Group) reload(ctx context.Context, key string) {
+	if !g.OriginHealthy() {
+		return // 数据源不可用期间保留旧值
+	}
+	if g.peers != nil {
+		if _, ok := g.pickPeer(key); ok {
+			return
+		}
+	}
+	_, err := g.loader.Do(key, func() (interface{}, error) {
+		load := g.inflight.begin(key)
+		if err := g.waitOrigin(ctx); err != nil {
+			g.inflight.end(load)
+			return nil, err
+		}
+		b, err := g.callGetter(ctx, key)
+		cacheable := g.cacheable(load) // 刷新期间被删除的结果不回填
+		if err != nil {
+			return nil, err
+		}
+		value := g.loadedView(key, b)
+		if cacheable {
+			g.populateCache(key, value)
+		}
+		return value, nil
+	})
+	if err != nil {
+		g.logger().Warn("scheduled refresh", "group", g.name, "key", key, "err", err)
+	}
+}
@@ -0,0 +1,87 @@
+package geecache
+
+import (
+	"github.com/golang/protobuf/proto"
+	pb "github/lhh-gh/geecache/geecachepb"
+	"sync"
+)
+
+// Serializer encodes the body of peer Get responses. The client names the
+// encoding it wants in the Accept header; a server that does not know it
+// answers with raw bytes, so nodes with different serializers interoperate.
+type Serializer interface {
+	// ContentType identifies the encoding in Accept and Content-Type headers.
+	ContentType() string
+	Marshal(res *pb.Response) ([]byte, error)
+	Unmarshal(data []byte, res *pb.Response) error
+}
+
+var (
+	// ProtoSerializer encodes responses as geecachepb.Response. It is the
+	// default of HTTPPool.
+	ProtoSerializer Serializer = protoSerializer{}
+	// RawSerializer sends the bare value, as peers did before serializers
+	// existed. It is what servers answer when the client names no encoding.
+	RawSerializer Serializer = rawSerializer{}
+)
+
+var (
+	serializersMu sync.RWMutex
+	serializers   = map[string]Serializer{
+		ProtoSerializer.ContentType(): ProtoSerializer,
+		RawSerializer.ContentType():   RawSerializer,
+	}
+)
+
+// RegisterSerializer makes s available to servers answering peers that ask
+// for its content type. WithSerializer registers its serializer.
+func RegisterSerializer(s Serializer) {
+	serializersMu.Lock()
+	defer serializersMu.Unlock()
+	serializers[s.ContentType()] = s
+}
+
+// serializerFor returns the serializer of contentType, or RawSerializer.
+func serializerFor(contentType string) Serializer {
+	serializersMu.RLock()
+	defer serializersMu.RUnlock()
+	if s, ok := serializers[contentType]; ok {
+		return s
+	}
+	return RawSerializer
+}
+
+// WithSerializer sets the encoding the pool asks its peers for.
+func WithSerializer(s Serializer) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if s != nil {
+			RegisterSerializer(s)
+			p.serializer = s
+		}
+	}
+}
+
+type protoSerializer struct{}
+
+func (protoSerializer) ContentType() string { return "application/x-protobuf" }
+
+func (protoSerializer) Marshal(res *pb.Response) ([]byte, error) {
+	return proto.Marshal(res)
+}
+
+func (protoSerializer) Unmarshal(data []byte, res *pb.Response) error {
+	return proto.Unmarshal(data, res)
+}
+
+type rawSerializer struct{}
+
+func (rawSerializer) ContentType() string { return "application/octet-stream" }
+
+func (rawSerializer) Marshal(res *pb.Response) ([]byte, error) {
+	return res.Value, nil
+}
+
+func (rawSerializer) Unmarshal(data []byte, res *pb.Response) error {
+	res.Value = data
+	return nil
+}
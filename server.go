@@ -0,0 +1,189 @@
+package geecache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// handoffConcurrency bounds the values a handoff pushes at once.
+const handoffConcurrency = 8
+
+// WithDeregister sets a hook Shutdown calls first, to remove this node from
+// service discovery, e.g. the Close method of a discovery.EtcdRegistration.
+func WithDeregister(fn func() error) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.deregister = fn
+	}
+}
+
+// WithHandoff makes Shutdown push up to n of each group's most recently used
+// entries, values and expiry included, to the nodes that take them over, so
+// they are not all loaded from the origin at once after this node exits and
+// values that exist only in the cache (Set without a Setter, Increment,
+// Append) survive. A node keeps its own copy of a key it already holds.
+// 0 disables the handoff.
+func WithHandoff(n int) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if n >= 0 {
+			p.handoffKeys = n
+		}
+	}
+}
+
+// ListenAndServe serves the pool on addr until Shutdown is called, after
+// which it returns http.ErrServerClosed.
+func (p *HTTPPool) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return p.Serve(l)
+}
+
+// Serve serves the pool on l until Shutdown is called, after which it
+// returns http.ErrServerClosed.
+func (p *HTTPPool) Serve(l net.Listener) error {
+	srv := &http.Server{Handler: p.Handler()}
+	p.mu.Lock()
+	if p.server != nil {
+		p.mu.Unlock()
+		l.Close()
+		return errors.New("geecache: pool is already serving")
+	}
+	p.server = srv
+	p.mu.Unlock()
+	return srv.Serve(l)
+}
+
+// Shutdown takes this node out of the cluster without dropping requests:
+//
+//  1. the WithDeregister hook removes it from service discovery;
+//  2. Drain moves its keys to their ring successors and waits for the
+//     peer requests in flight;
+//  3. with WithHandoff, its hot entries are pushed to their new owners;
+//  4. the server started by ListenAndServe or Serve is shut down.
+//
+// ctx bounds the whole procedure. Every step runs even if an earlier one
+// fails; the errors are joined.
+func (p *HTTPPool) Shutdown(ctx context.Context) error {
+	var errs []error
+	if p.deregister != nil {
+		if err := p.deregister(); err != nil {
+			errs = append(errs, fmt.Errorf("deregistering: %w", err))
+		}
+	}
+	if err := p.Drain(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("draining: %w", err))
+	}
+	if p.handoffKeys > 0 {
+		if err := p.handoff(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("handing off: %w", err))
+		}
+	}
+
+	p.mu.Lock()
+	srv := p.server
+	p.mu.Unlock()
+	if srv != nil {
+		if err := srv.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("shutting down server: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// handoff pushes each group's hot entries to their new owners, at most
+// handoffConcurrency at a time.
+func (p *HTTPPool) handoff(ctx context.Context) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+		sem  = make(chan struct{}, handoffConcurrency)
+	)
+	push := func(h *httpGetter, g *Group, key string, v ByteView) {
+		defer func() { <-sem; wg.Done() }()
+		if err := h.handoff(ctx, g.name, key, v); err != nil {
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%s/%s to %s: %w", g.name, key, h.baseURL, err))
+			mu.Unlock()
+		}
+	}
+	for _, g := range p.registry.snapshot() {
+		for _, key := range g.mainCache.hotKeys(p.handoffKeys) {
+			h := p.successor(g.routingKey(key))
+			if h == nil {
+				continue
+			}
+			v, ok := g.getMain(key)
+			if !ok {
+				continue // expired or removed since
+			}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return errors.Join(append(errs, ctx.Err())...)
+			}
+			wg.Add(1)
+			go push(h, g, key, v)
+		}
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// handoff sends value to the peer, which caches it unless it already holds
+// key. The expiry travels along in unix nanoseconds.
+func (h *httpGetter) handoff(ctx context.Context, group, key string, value ByteView) error {
+	q := url.Values{"op": {"set"}}
+	if !value.e.IsZero() {
+		q.Set("expire", strconv.FormatInt(value.e.UnixNano(), 10))
+	}
+	res, err := h.do(ctx, http.MethodPost, group, key, q.Encode(), bytes.NewReader(value.b))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK {
+		return serverError(res)
+	}
+	return nil
+}
+
+// errHeld reports that fillLocally found key already cached.
+var errHeld = errors.New("already held")
+
+// fillLocally caches a handed-off value unless key is already cached here,
+// where it may have been written since the ring changed.
+func (g *Group) fillLocally(key string, value ByteView) error {
+	if value.expired(time.Now()) || g.oversized(value) {
+		return nil
+	}
+	err := g.updateMain(key, func(_ ByteView, ok bool) (ByteView, error) {
+		if ok {
+			return ByteView{}, errHeld
+		}
+		return value, nil
+	})
+	if err == errHeld || errors.Is(err, ErrNoCapacity) {
+		return nil
+	}
+	return err
+}
+
+// successor returns the getter of the peer that owns key once self has left
+// the ring, or nil if there is none.
+func (p *HTTPPool) successor(key string) *httpGetter {
+	ring := p.ring()
+	peer := ring.peers.GetExcept(key, func(node string) bool { return node == p.self })
+	return ring.getters[peer]
+}
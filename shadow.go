@@ -0,0 +1,47 @@
+package geecache
+
+import (
+	"bytes"
+	"context"
+	"time"
+)
+
+// WithShadowSample 按比例对命中的Get旁路回源，持续度量缓存的正确性与收益
+// 调用方照常拿到缓存值；被抽中的请求在后台再向Getter加载一次，并与缓存值比较：
+//   - ShadowMismatches 缓存值与数据源不一致的次数（过期数据、写入遗漏等）
+//   - ShadowLatency 旁路回源的平均耗时，即没有缓存时这些请求的大致延迟
+//
+// 旁路加载不写入缓存，占用回源预算与后台任务配额（配额用尽时跳过）。
+// rate取值(0, 1]，建议很小（如0.001）；<=0 表示关闭
+func WithShadowSample(rate float64) GroupOption {
+	return func(g *Group) {
+		if rate > 1 {
+			rate = 1
+		}
+		if rate > 0 {
+			g.shadowRate = rate
+		}
+	}
+}
+
+// maybeShadow 按WithShadowSample抽样，对命中的值在后台旁路回源并比较
+func (g *Group) maybeShadow(key string, cached ByteView) {
+	if g.shadowRate <= 0 || g.rand.Float64() >= g.shadowRate {
+		return
+	}
+	g.bg.goTask(func(ctx context.Context) {
+		if err := g.waitOrigin(ctx); err != nil {
+			return
+		}
+		start := time.Now()
+		b, err := g.getter.GetContext(ctx, key)
+		g.stats.shadowNanos.Add(int64(time.Since(start)))
+		g.stats.shadowLoads.Add(1)
+		switch {
+		case err != nil:
+			g.stats.shadowErrors.Add(1)
+		case !bytes.Equal(b, cached.b):
+			g.stats.shadowMismatches.Add(1)
+		}
+	})
+}
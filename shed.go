@@ -0,0 +1,46 @@
+package geecache
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRetryAfter is the Retry-After hint sent with shed requests.
+const defaultRetryAfter = time.Second
+
+// WithMaxInflight caps the number of peer requests served concurrently.
+// Requests beyond the cap are rejected at once with 503 and a Retry-After
+// header instead of queueing, so an overloaded node degrades predictably.
+// retryAfter <= 0 uses a one second hint; n <= 0 disables the cap.
+func WithMaxInflight(n int, retryAfter time.Duration) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		if retryAfter <= 0 {
+			retryAfter = defaultRetryAfter
+		}
+		p.maxInflight = int64(n)
+		p.retryAfter = retryAfter
+	}
+}
+
+// Shed returns the number of peer requests rejected by WithMaxInflight.
+func (p *HTTPPool) Shed() int64 {
+	return p.shed.Load()
+}
+
+// admit reserves an in-flight slot. It writes the 503 and returns false
+// when the node is at capacity; otherwise the caller must call release.
+func (p *HTTPPool) admit(w http.ResponseWriter) (release func(), ok bool) {
+	if p.maxInflight <= 0 {
+		return func() {}, true
+	}
+	if p.inflight.Add(1) > p.maxInflight {
+		p.inflight.Add(-1)
+		p.shed.Add(1)
+		secs := int((p.retryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		writeError(w, "overloaded", http.StatusServiceUnavailable)
+		return nil, false
+	}
+	return func() { p.inflight.Add(-1) }, true
+}
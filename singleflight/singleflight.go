@@ -1,6 +1,10 @@
 package singleflight
 
-import "sync"
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
 
 // call 表示一个正在执行或已完成的函数调用
 // 设计要点：
@@ -10,6 +14,18 @@ type call struct {
 	wg  sync.WaitGroup // 用于阻塞等待的同步原语
 	val interface{}    // 函数调用返回的结果值
 	err error          // 函数调用返回的错误信息
+
+	panicked bool // fn是否发生panic（此时err为*PanicError）
+
+	dups  int             // 共享本次调用结果的重复请求数（受Group.mu保护）
+	chans []chan<- Result // DoChan调用方的结果通道（受Group.mu保护）
+}
+
+// Result 保存Do的结果，通过DoChan返回的通道传递
+type Result struct {
+	Val    interface{}
+	Err    error
+	Shared bool // 结果是否被多个调用方共享
 }
 
 // Group 单飞机制的核心控制器
@@ -44,8 +60,12 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 
 	// 存在进行中的调用
 	if c, ok := g.m[key]; ok {
+		c.dups++
 		g.mu.Unlock() // 注意：必须先解锁再等待
 		c.wg.Wait()   // 阻塞等待调用完成
+		if c.panicked {
+			panic(c.err)
+		}
 		return c.val, c.err
 	}
 
@@ -56,13 +76,76 @@ func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, err
 	g.mu.Unlock() // 关键：提前释放锁，允许其他请求进入
 
 	// 第二阶段：执行实际函数调用（无锁状态）
-	c.val, c.err = fn()
-	c.wg.Done() // 通知所有等待者调用完成
+	g.doCall(c, key, fn)
+	if c.panicked {
+		panic(c.err)
+	}
+	return c.val, c.err
+}
 
-	// 第三阶段：清理调用记录
+// DoChan 与Do相同，但不阻塞调用方，结果就绪后写入返回的通道
+// 调用方可以用select等待、自行设置超时，或将结果接入其他流水线；
+// 放弃等待不会取消fn的执行，通道带缓冲，未读取的结果不会阻塞执行者
+func (g *Group) DoChan(key string, fn func() (interface{}, error)) <-chan Result {
+	ch := make(chan Result, 1)
 	g.mu.Lock()
-	delete(g.m, key) // 及时移除已完成条目
+	if g.m == nil {
+		g.m = make(map[string]*call)
+	}
+	if c, ok := g.m[key]; ok {
+		c.dups++
+		c.chans = append(c.chans, ch)
+		g.mu.Unlock()
+		return ch
+	}
+	c := &call{chans: []chan<- Result{ch}}
+	c.wg.Add(1)
+	g.m[key] = c
 	g.mu.Unlock()
 
-	return c.val, c.err
+	go g.doCall(c, key, fn)
+	return ch
+}
+
+// PanicError fn执行期间发生panic时传递给所有等待者
+// Do的调用方会以它重新panic，DoChan的调用方则在Result.Err中收到它
+type PanicError struct {
+	Value interface{} // recover得到的值
+	Stack []byte      // panic发生时的调用栈
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("singleflight: fn panicked: %v\n\n%s", p.Value, p.Stack)
+}
+
+// doCall 执行fn，通知所有等待者并清理调用记录
+// fn发生panic时同样完成通知与清理，否则等待者会永久阻塞、key无法再被加载
+func (g *Group) doCall(c *call, key string, fn func() (interface{}, error)) {
+	defer func() {
+		if r := recover(); r != nil {
+			c.val, c.err = nil, &PanicError{Value: r, Stack: debug.Stack()}
+			c.panicked = true
+		}
+		c.wg.Done() // 通知所有Do等待者调用完成
+
+		// 清理调用记录，并把结果发给DoChan调用方
+		g.mu.Lock()
+		if g.m[key] == c {
+			delete(g.m, key) // 及时移除已完成条目（已被Forget时不影响新调用）
+		}
+		for _, ch := range c.chans {
+			ch <- Result{Val: c.val, Err: c.err, Shared: c.dups > 0}
+		}
+		g.mu.Unlock()
+	}()
+	c.val, c.err = fn()
+}
+
+// Forget 丢弃key正在执行的调用记录，之后的调用方会重新执行函数，
+// 而不是等待当前调用的结果；已在等待的调用方仍获得原结果。
+// 适用于已知本次加载将以暂时性失败结束、需要尽快重试的场景
+func (g *Group) Forget(key string) {
+	g.mu.Lock()
+	delete(g.m, key)
+	g.mu.Unlock()
 }
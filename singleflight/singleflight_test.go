@@ -2,6 +2,7 @@ package singleflight
 
 import (
 	"testing"
+	"time"
 )
 
 func TestDo(t *testing.T) {
@@ -14,3 +15,88 @@ func TestDo(t *testing.T) {
 		t.Errorf("Do v = %v, error = %v", v, err)
 	}
 }
+
+func TestDoChan(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	fn := func() (interface{}, error) {
+		<-release
+		return "bar", nil
+	}
+	ch1 := g.DoChan("key", fn)
+	ch2 := g.DoChan("key", fn)
+
+	select {
+	case <-ch1:
+		t.Fatal("expect DoChan not to block on the result")
+	default:
+	}
+	close(release)
+
+	for _, ch := range []<-chan Result{ch1, ch2} {
+		select {
+		case res := <-ch:
+			if res.Val != "bar" || res.Err != nil || !res.Shared {
+				t.Errorf("DoChan result = %+v", res)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for DoChan")
+		}
+	}
+}
+
+func TestForget(t *testing.T) {
+	var g Group
+	release := make(chan struct{})
+	first := g.DoChan("key", func() (interface{}, error) {
+		<-release
+		return 1, nil
+	})
+
+	g.Forget("key")
+	v, _ := g.Do("key", func() (interface{}, error) { return 2, nil })
+	if v != 2 {
+		t.Errorf("expect a new call after Forget, got %v", v)
+	}
+
+	close(release)
+	if res := <-first; res.Val != 1 {
+		t.Errorf("expect the forgotten call to finish with its own result, got %v", res.Val)
+	}
+}
+
+func TestDoPanic(t *testing.T) {
+	var g Group
+	started, release := make(chan struct{}), make(chan struct{})
+	waiter := g.DoChan("key", func() (interface{}, error) {
+		close(started)
+		<-release
+		panic("boom")
+	})
+	<-started
+
+	done := make(chan interface{})
+	go func() {
+		defer func() { done <- recover() }()
+		g.Do("key", func() (interface{}, error) { return nil, nil })
+	}()
+	time.Sleep(10 * time.Millisecond) // 让Do加入等待
+	close(release)
+
+	select {
+	case r := <-done:
+		if pe, ok := r.(*PanicError); !ok || pe.Value != "boom" {
+			t.Errorf("expect Do to re-panic with the PanicError, got %v", r)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter deadlocked after fn panicked")
+	}
+	if res := <-waiter; res.Err == nil {
+		t.Error("expect DoChan to receive the panic as an error")
+	}
+
+	v, err := g.Do("key", func() (interface{}, error) { return "ok", nil })
+	if v != "ok" || err != nil {
+		t.Errorf("expect the key to be usable after a panic, got %v, %v", v, err)
+	}
+}
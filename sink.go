@@ -0,0 +1,59 @@
+package geecache
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Sink Get结果的接收方，见GetInto
+// 用途：把缓存中的字节直接解码到调用方的目标中，省去ByteSlice的复制与再次解码
+type Sink interface {
+	// SetBytes 以b填充目标
+	// b直接引用缓存内部的数据：实现只能读取，不得修改，返回后也不得保留
+	SetBytes(b []byte) error
+}
+
+// GetInto 读取key并写入dest，语义同GetContext
+func (g *Group) GetInto(ctx context.Context, key string, dest Sink) error {
+	v, err := g.GetContext(ctx, key)
+	if err != nil {
+		return err
+	}
+	return dest.SetBytes(v.b)
+}
+
+// ByteSink 返回把值复制到*dst的Sink，复用*dst已有的容量
+func ByteSink(dst *[]byte) Sink {
+	return byteSink{dst}
+}
+
+type byteSink struct{ dst *[]byte }
+
+func (s byteSink) SetBytes(b []byte) error {
+	*s.dst = append((*s.dst)[:0], b...)
+	return nil
+}
+
+// StringSink 返回把值写入*dst的Sink
+func StringSink(dst *string) Sink {
+	return stringSink{dst}
+}
+
+type stringSink struct{ dst *string }
+
+func (s stringSink) SetBytes(b []byte) error {
+	*s.dst = string(b)
+	return nil
+}
+
+// ProtoSink 返回把值按protobuf解码到m的Sink，m原有内容被覆盖
+func ProtoSink(m proto.Message) Sink {
+	return protoSink{m}
+}
+
+type protoSink struct{ m proto.Message }
+
+func (s protoSink) SetBytes(b []byte) error {
+	return proto.Unmarshal(b, s.m)
+}
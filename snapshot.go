@@ -0,0 +1,66 @@
+package geecache
+
+import "time"
+
+// Snapshot 是Group缓存内容在某一时刻的只读视图
+// 用途：分析/导出任务遍历缓存而不长时间阻塞写入
+type Snapshot struct {
+	entries []snapshotEntry
+}
+
+type snapshotEntry struct {
+	key   string
+	value ByteView
+}
+
+// SnapshotView 返回本节点mainCache与hotCache中未过期条目的快照
+// 一致性：
+//   - 逐个分片短暂加锁复制条目视图（不复制值数据），复制期间其他分片照常读写
+//   - 每个分片内是一致的时间点，分片之间不保证是同一时刻
+//   - 同一key同时在mainCache与hotCache中时只保留mainCache的值
+//   - 配置了WithTransformers时返回解码后的值，解码失败的条目被跳过
+//
+// 快照生成后不受后续写入、删除与淘汰影响
+func (g *Group) SnapshotView() *Snapshot {
+	now := time.Now()
+	entries := g.mainCache.appendEntries(nil, now)
+	n := len(entries)
+	entries = g.hotCache.appendEntries(entries, now)
+	if len(entries) > n {
+		seen := make(map[string]bool, n)
+		for _, e := range entries[:n] {
+			seen[e.key] = true
+		}
+		kept := entries[:n]
+		for _, e := range entries[n:] {
+			if !seen[e.key] {
+				kept = append(kept, e)
+			}
+		}
+		entries = kept
+	}
+	if len(g.transformers) > 0 {
+		decoded := entries[:0]
+		for _, e := range entries {
+			if v, _, err := g.decode(e.key, e.value); err == nil {
+				decoded = append(decoded, snapshotEntry{key: e.key, value: v})
+			}
+		}
+		entries = decoded
+	}
+	return &Snapshot{entries: entries}
+}
+
+// Len 返回快照中的条目数
+func (s *Snapshot) Len() int {
+	return len(s.entries)
+}
+
+// Range 依次访问快照中的条目，fn返回false时停止
+func (s *Snapshot) Range(fn func(key string, value ByteView) bool) {
+	for _, e := range s.entries {
+		if !fn(e.key, e.value) {
+			return
+		}
+	}
+}
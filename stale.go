@@ -0,0 +1,50 @@
+package geecache
+
+import (
+	"context"
+	"time"
+)
+
+// WithStaleWhileRevalidate 启用过期后台刷新模式
+// 条目过期后的window时长内，Get立即返回旧值，同时在后台回源刷新，
+// 以轻微的陈旧换取热点key上零尾延迟；超过window的条目按未命中处理。
+// 同一key的刷新经singleflight去重，并受Group后台任务上限约束
+func WithStaleWhileRevalidate(window time.Duration) GroupOption {
+	return func(g *Group) {
+		if window > 0 {
+			g.staleWindow = window
+		}
+	}
+}
+
+// serveStale 返回过期不久的旧值，并触发该key的后台刷新
+// 数据源不可用（见WithOriginProbe）时返回任意旧的值，且不触发刷新
+func (g *Group) serveStale(key string) (ByteView, bool) {
+	window := g.staleWindowNow()
+	if window <= 0 {
+		return ByteView{}, false
+	}
+	v, ok := g.mainCache.getStale(key, window)
+	if v, ok = g.decodeHit(key, v, ok, g.mainCache); !ok {
+		return ByteView{}, false
+	}
+	g.stats.staleServed.Add(1)
+	if g.OriginHealthy() {
+		g.refresh(key)
+	}
+	return v, true
+}
+
+// refresh 在后台重新加载key，已在刷新中的key不会重复提交
+func (g *Group) refresh(key string) {
+	if _, busy := g.refreshing.LoadOrStore(key, struct{}{}); busy {
+		return
+	}
+	started := g.bg.goTask(func(ctx context.Context) {
+		defer g.refreshing.Delete(key)
+		g.load(ctx, key)
+	})
+	if !started {
+		g.refreshing.Delete(key)
+	}
+}
@@ -0,0 +1,109 @@
+package geecache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// groupStats Group运行时计数器（原子操作，无需加锁）
+type groupStats struct {
+	gets          atomic.Int64 // Get请求总数
+	hits          atomic.Int64 // 命中mainCache或hotCache的次数
+	loads         atomic.Int64 // 未命中后进入加载流程的次数
+	loadsDeduped  atomic.Int64 // singleflight合并后实际执行的加载次数
+	localLoads    atomic.Int64 // 本地Getter加载成功次数
+	localLoadErrs atomic.Int64 // 本地Getter加载失败次数
+	peerLoads     atomic.Int64 // 远端节点获取成功次数
+	peerErrors    atomic.Int64 // 远端节点获取失败次数
+	peerRetries   atomic.Int64 // 远端节点获取的重试次数
+	fallbackLoads atomic.Int64 // 所属节点不可达时本节点回退加载成功次数
+	oversized     atomic.Int64 // 因超过WithMaxEntryBytes未缓存的值
+	staleServed   atomic.Int64 // 过期后台刷新模式下返回旧值的次数
+	expiredSwept  atomic.Int64 // 后台清理任务清除的过期条目数
+	l2Hits        atomic.Int64 // 从L2读取的次数
+
+	loadsInvalidated atomic.Int64 // 加载期间被删除、结果未回填的加载次数
+
+	cacheErrors atomic.Int64 // 缓存自身的错误次数（编解码、L2等）
+	bypassTrips atomic.Int64 // 因缓存错误过多进入直连数据源模式的次数
+	bypassed    atomic.Int64 // 直连数据源模式下处理的Get次数
+
+	shadowLoads      atomic.Int64 // 完成的旁路回源次数
+	shadowErrors     atomic.Int64 // 旁路回源失败次数
+	shadowMismatches atomic.Int64 // 旁路回源结果与缓存值不一致的次数
+	shadowNanos      atomic.Int64 // 旁路回源累计耗时（纳秒）
+}
+
+// CacheStats Group统计信息的快照
+// 用途：以编程方式检查缓存效果（命中率、回源比例、去重效果等）
+type CacheStats struct {
+	Gets          int64 // Get请求总数
+	Hits          int64 // 缓存命中次数
+	Misses        int64 // 缓存未命中次数（Gets - Hits）
+	Loads         int64 // 未命中后进入加载流程的次数
+	LoadsDeduped  int64 // singleflight合并后实际执行的加载次数
+	LocalLoads    int64 // 本地Getter加载成功次数
+	LocalLoadErrs int64 // 本地Getter加载失败次数
+	PeerLoads     int64 // 远端节点获取成功次数
+	PeerErrors    int64 // 远端节点获取失败次数（重试后仍失败的计一次）
+	PeerRetries   int64 // 远端节点获取因瞬时失败重试的次数
+	FallbackLoads int64 // 所属节点不可达时本节点回退加载成功次数（见WithPeerFallback）
+	Bytes         int64 // mainCache与hotCache当前占用字节数
+	Items         int64 // mainCache与hotCache当前条目数
+	Oversized     int64 // 因超过WithMaxEntryBytes未缓存的值
+	StaleServed   int64 // 返回过期旧值的次数（计入Hits）
+	ExpiredSwept  int64 // 后台清理任务清除的过期条目数
+	L2Hits        int64 // mainCache未命中后从L2读取的次数
+
+	LoadsInvalidated int64 // 加载期间key被删除、结果未回填缓存的加载次数
+
+	CacheErrors int64 // 缓存自身的错误次数（编解码失败、L2读写失败、缓存查询panic）
+	BypassTrips int64 // 因缓存错误过多进入直连数据源模式的次数（见WithBypassOnErrors）
+	Bypassed    int64 // 直连数据源模式下处理的Get次数
+
+	ShadowLoads      int64         // 完成的旁路回源次数（见WithShadowSample）
+	ShadowErrors     int64         // 旁路回源失败次数
+	ShadowMismatches int64         // 旁路回源结果与缓存值不一致的次数
+	ShadowLatency    time.Duration // 旁路回源的平均耗时
+
+	BackgroundGoroutines int64 // 运行中的后台goroutine数
+	BackgroundDropped    int64 // 因超出上限或已关闭被丢弃的后台任务数
+}
+
+// Stats 返回Group当前的统计信息快照
+// 各计数器分别原子读取，快照内字段之间不保证严格一致
+func (g *Group) Stats() CacheStats {
+	s := CacheStats{
+		Gets:          g.stats.gets.Load(),
+		Hits:          g.stats.hits.Load(),
+		Loads:         g.stats.loads.Load(),
+		LoadsDeduped:  g.stats.loadsDeduped.Load(),
+		LocalLoads:    g.stats.localLoads.Load(),
+		LocalLoadErrs: g.stats.localLoadErrs.Load(),
+		PeerLoads:     g.stats.peerLoads.Load(),
+		PeerErrors:    g.stats.peerErrors.Load(),
+		PeerRetries:   g.stats.peerRetries.Load(),
+		FallbackLoads: g.stats.fallbackLoads.Load(),
+		Bytes:         g.mainCache.bytes() + g.hotCache.bytes(),
+		Items:         g.mainCache.items() + g.hotCache.items(),
+		Oversized:     g.stats.oversized.Load(),
+		StaleServed:   g.stats.staleServed.Load(),
+		ExpiredSwept:  g.stats.expiredSwept.Load(),
+		L2Hits:        g.stats.l2Hits.Load(),
+
+		BackgroundGoroutines: g.bg.running.Load(),
+		BackgroundDropped:    g.bg.dropped.Load(),
+	}
+	s.Misses = s.Gets - s.Hits
+	s.LoadsInvalidated = g.stats.loadsInvalidated.Load()
+	s.CacheErrors = g.stats.cacheErrors.Load()
+	s.BypassTrips = g.stats.bypassTrips.Load()
+	s.Bypassed = g.stats.bypassed.Load()
+	s.ShadowLoads = g.stats.shadowLoads.Load()
+	s.ShadowErrors = g.stats.shadowErrors.Load()
+	s.ShadowMismatches = g.stats.shadowMismatches.Load()
+	if s.ShadowLoads > 0 {
+		s.ShadowLatency = time.Duration(g.stats.shadowNanos.Load() / s.ShadowLoads)
+	}
+	return s
+}
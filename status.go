@@ -0,0 +1,52 @@
+package geecache
+
+import (
+	"net/http"
+	"time"
+)
+
+// Response headers describing how a request was served, see WithCacheStatus.
+const (
+	CacheStatusHeader = "X-Geecache-Status"
+	NodeHeader        = "X-Geecache-Node"
+)
+
+// Values of CacheStatusHeader.
+const (
+	StatusHit     = "HIT"      // served from this node's cache
+	StatusMiss    = "MISS"     // loaded from the origin
+	StatusStale   = "STALE"    // an expired value, served while it is refreshed
+	StatusPeerHit = "PEER-HIT" // fetched from the owning peer
+	StatusBypass  = "BYPASS"   // the cache was not consulted
+)
+
+// WithCacheStatus adds NodeHeader, naming this node, to every response
+// and CacheStatusHeader to get responses, so downstream services and CDNs
+// can see how each request was served.
+func WithCacheStatus() HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.cacheStatus = true
+	}
+}
+
+// CacheStatus classifies a value returned by a Group: hit reports whether
+// it came from this node's cache, as GetWithInfo does.
+func CacheStatus(v ByteView, hit bool) string {
+	switch {
+	case hit && v.expired(time.Now()):
+		return StatusStale
+	case hit:
+		return StatusHit
+	case v.src == SourcePeer:
+		return StatusPeerHit
+	default:
+		return StatusMiss
+	}
+}
+
+// setCacheStatus sets CacheStatusHeader when enabled.
+func (p *HTTPPool) setCacheStatus(w http.ResponseWriter, status string) {
+	if p.cacheStatus {
+		w.Header().Set(CacheStatusHeader, status)
+	}
+}
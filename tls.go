@@ -0,0 +1,55 @@
+package geecache
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// LoadMutualTLS builds a config for mutually authenticated peer traffic:
+// every node presents the certificate in certFile/keyFile, and accepts
+// only peers whose certificates are signed by a CA in caFile. The same
+// config serves both sides, see WithTLSConfig and ListenAndServeTLS.
+func LoadMutualTLS(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading key pair: %w", err)
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading CA: %w", err)
+	}
+	cas := x509.NewCertPool()
+	if !cas.AppendCertsFromPEM(pem) {
+		return nil, errors.New("loading CA: no certificates in " + caFile)
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      cas,
+		ClientCAs:    cas,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// WithTLSConfig makes the pool reach its peers over TLS with conf,
+// presenting conf's certificates to peers that require client
+// certificates. Peers must be addressed with https:// URLs.
+func WithTLSConfig(conf *tls.Config) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.TLSClientConfig = conf.Clone()
+		p.transport = t
+	}
+}
+
+// ListenAndServeTLS serves handler (usually an HTTPPool) over TLS on addr
+// with conf, e.g. from LoadMutualTLS, which rejects peers without a valid
+// client certificate.
+func ListenAndServeTLS(addr string, handler http.Handler, conf *tls.Config) error {
+	srv := &http.Server{Addr: addr, Handler: handler, TLSConfig: conf}
+	return srv.ListenAndServeTLS("", "")
+}
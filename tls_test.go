@@ -0,0 +1,82 @@
+package geecache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert 生成由parent签发的证书（parent为nil时自签名CA），写入dir并返回文件路径
+func writeCert(t *testing.T, dir, name string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey, string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		tmpl.IsCA, tmpl.BasicConstraintsValid = true, true
+		tmpl.KeyUsage = x509.KeyUsageCertSign
+		parent, parentKey = tmpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	keyDER, _ := x509.MarshalECPrivateKey(key)
+	certFile, keyFile := filepath.Join(dir, name+".pem"), filepath.Join(dir, name+"-key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return cert, key, certFile, keyFile
+}
+
+func TestMutualTLS(t *testing.T) {
+	NewGroup("mtls", 2<<10, GetterFunc(
+		func(key string) ([]byte, error) { return []byte(key), nil }))
+	dir := t.TempDir()
+	ca, caKey, caFile, _ := writeCert(t, dir, "ca", nil, nil)
+	_, _, certFile, keyFile := writeCert(t, dir, "node", ca, caKey)
+	conf, err := LoadMutualTLS(certFile, keyFile, caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(NewHTTPPool("self"))
+	srv.TLS = conf
+	srv.StartTLS()
+	defer srv.Close()
+
+	p := NewHTTPPool("self", WithTLSConfig(conf))
+	p.Set(srv.URL)
+	peer, _ := p.PickPeer("k")
+	if b, err := peer.Get(context.Background(), "mtls", "k"); err != nil || string(b) != "k" {
+		t.Fatalf("expect the mTLS fetch to succeed, got %q, %v", b, err)
+	}
+
+	anon := &http.Client{Transport: &http.Transport{TLSClientConfig: conf.Clone()}}
+	anon.Transport.(*http.Transport).TLSClientConfig.Certificates = nil
+	if res, err := anon.Get(srv.URL + defaultBasePath + "mtls/k"); err == nil {
+		res.Body.Close()
+		t.Fatal("expect a peer without a client certificate to be rejected")
+	}
+}
@@ -0,0 +1,55 @@
+package geecache
+
+import (
+	"context"
+	"net/http"
+)
+
+// Tracer 为读取路径创建追踪span，用于对接外部追踪系统（如OpenTelemetry）
+// 设计要点：
+//  1. Group通过WithTracer记录Get、加载（含singleflight等待）、Getter回源与远端获取
+//  2. HTTPPool通过WithTracePropagation在节点间请求中传递追踪上下文，
+//     一次慢读取可以跨节点端到端追踪
+//  3. 实现必须并发安全；未配置时不产生任何额外开销
+//
+// 参见 tracing 子包提供的OpenTelemetry实现
+type Tracer interface {
+	// Start 开始名为op的span，返回携带该span的ctx与结束函数（传入操作的错误）
+	Start(ctx context.Context, op, group, key string) (context.Context, func(err error))
+	// Inject 将ctx中的追踪上下文写入节点间请求头
+	Inject(ctx context.Context, h http.Header)
+	// Extract 由节点间请求头还原追踪上下文
+	Extract(ctx context.Context, h http.Header) context.Context
+}
+
+// span名称
+const (
+	spanGet    = "geecache.Get"    // 一次Get（含命中）
+	spanLoad   = "geecache.load"   // 未命中后的加载，含等待其他请求进行中的同一加载
+	spanGetter = "geecache.getter" // Getter回源
+	spanPeer   = "geecache.peer"   // 一次远端获取（每次重试各一个）
+	spanServe  = "geecache.serve"  // 节点处理来自其他节点的请求
+)
+
+// WithTracer 为Group配置追踪
+func WithTracer(t Tracer) GroupOption {
+	return func(g *Group) {
+		g.tracer = t
+	}
+}
+
+// WithTracePropagation 让HTTPPool在节点间请求中传递t的追踪上下文，
+// 并为收到的节点请求创建span
+func WithTracePropagation(t Tracer) HTTPPoolOption {
+	return func(p *HTTPPool) {
+		p.tracer = t
+	}
+}
+
+// startSpan 在配置了Tracer时开始span
+func (g *Group) startSpan(ctx context.Context, op, key string) (context.Context, func(err error)) {
+	if g.tracer == nil {
+		return ctx, func(error) {}
+	}
+	return g.tracer.Start(ctx, op, g.name, key)
+}
@@ -0,0 +1,74 @@
+// Package tracing 提供基于OpenTelemetry的 geecache.Tracer 实现
+//
+// 典型用法：
+//
+//	tr := tracing.New(nil, nil)
+//	geecache.NewGroup("scores", 2<<10, getter, geecache.WithTracer(tr))
+//	pool := geecache.NewHTTPPool(self, geecache.WithTracePropagation(tr))
+package tracing
+
+import (
+	"context"
+	"net/http"
+
+	"github/lhh-gh/geecache"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName OpenTelemetry中标识本库的instrumentation scope
+const instrumentationName = "github/lhh-gh/geecache"
+
+// Tracer 以OpenTelemetry span记录读取路径
+// span属性：
+//   - geecache.group 缓存组名
+//   - geecache.key   缓存key（批量回源时不设置）
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ geecache.Tracer = (*Tracer)(nil)
+
+// New 创建Tracer
+// tp 为nil时使用全局TracerProvider，propagator 为nil时使用全局TextMapPropagator
+// （通常为W3C traceparent）
+func New(tp trace.TracerProvider, propagator propagation.TextMapPropagator) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName), propagator: propagator}
+}
+
+// Start 实现geecache.Tracer：开始span，结束时记录错误
+func (t *Tracer) Start(ctx context.Context, op, group, key string) (context.Context, func(err error)) {
+	attrs := []attribute.KeyValue{attribute.String("geecache.group", group)}
+	if key != "" {
+		attrs = append(attrs, attribute.String("geecache.key", key))
+	}
+	ctx, span := t.tracer.Start(ctx, op, trace.WithAttributes(attrs...))
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// Inject 实现geecache.Tracer：将追踪上下文写入节点间请求头
+func (t *Tracer) Inject(ctx context.Context, h http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(h))
+}
+
+// Extract 实现geecache.Tracer：由节点间请求头还原追踪上下文
+func (t *Tracer) Extract(ctx context.Context, h http.Header) context.Context {
+	return t.propagator.Extract(ctx, propagation.HeaderCarrier(h))
+}
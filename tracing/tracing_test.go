@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github/lhh-gh/geecache"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracer(t *testing.T) {
+	exp := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exp))
+	tr := New(tp, propagation.TraceContext{})
+	g := geecache.NewGroup("tracing", 2<<10, geecache.GetterFunc(
+		func(key string) ([]byte, error) {
+			if key == "missing" {
+				return nil, fmt.Errorf("%s not exist", key)
+			}
+			return []byte("value"), nil
+		}), geecache.WithTracer(tr))
+
+	g.Get("k")
+	g.Get("missing")
+
+	spans := exp.GetSpans()
+	if len(spans) != 6 { // 每次Get：getter、load、Get
+		t.Fatalf("expect 6 spans, got %d", len(spans))
+	}
+	get := spans[2]
+	if get.Name != "geecache.Get" || spans[0].Parent.SpanID() != spans[1].SpanContext.SpanID() ||
+		spans[1].Parent.SpanID() != get.SpanContext.SpanID() {
+		t.Fatalf("expect getter < load < Get, got %s < %s < %s", spans[0].Name, spans[1].Name, get.Name)
+	}
+	if spans[5].Status.Code != codes.Error {
+		t.Fatalf("expect the failed Get to be marked as an error, got %v", spans[5].Status)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	tp := sdktrace.NewTracerProvider()
+	tr := New(tp, propagation.TraceContext{})
+
+	ctx, end := tr.Start(context.Background(), "geecache.peer", "g", "k")
+	defer end(nil)
+	h := make(http.Header)
+	tr.Inject(ctx, h)
+	remote := trace.SpanContextFromContext(tr.Extract(context.Background(), h))
+	if local := trace.SpanContextFromContext(ctx); remote.TraceID() != local.TraceID() || !remote.IsRemote() {
+		t.Fatalf("expect the trace to continue on the peer, got %v", remote)
+	}
+}
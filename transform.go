@@ -0,0 +1,178 @@
+package geecache
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Transformer 值转换器：写入本节点缓存前编码、读出时解码
+// 用于压缩、加密、信封版本、结构迁移等按字节处理的横切需求，
+// 多个转换器组成管道，而不是各自成为专门的选项
+//
+// 约定：
+//  1. Decode(Encode(v)) 必须还原v；两者都不得修改传入的切片
+//  2. 实现必须并发安全
+//  3. Decode失败的条目被删除并按未命中处理（例如旧版本数据无法迁移时重新回源）
+type Transformer interface {
+	Encode(key string, value []byte) ([]byte, error)
+	Decode(key string, stored []byte) ([]byte, error)
+}
+
+// Migrator 可选接口：Transformer同时实现时，解码前询问该层的存储值是否为旧格式
+// 旧格式的值解码成功后按当前管道重新编码并写回缓存，下次读取不必再迁移
+type Migrator interface {
+	Outdated(stored []byte) bool
+}
+
+// WithTransformers 为Group配置值转换管道
+// 写入时按顺序调用Encode，读出时按相反顺序调用Decode。
+// 作用范围：mainCache、hotCache、L2与快照中存放的都是编码后的值，
+// 容量、WithMaxEntryBytes与淘汰回调看到的也是编码后的值；
+// Get等读取接口、节点间传输与Getter看到的都是原始值
+func WithTransformers(ts ...Transformer) GroupOption {
+	return func(g *Group) {
+		g.transformers = append(g.transformers, ts...)
+	}
+}
+
+// encode 依次应用转换器，返回待写入缓存的值（保留过期时间与来源）
+func (g *Group) encode(key string, value ByteView) (ByteView, error) {
+	b := value.b
+	for _, t := range g.transformers {
+		var err error
+		if b, err = t.Encode(key, b); err != nil {
+			return ByteView{}, err
+		}
+	}
+	value.b = b
+	return value, nil
+}
+
+// decode 按相反顺序应用转换器，还原缓存中的值
+// outdated 表示某一层报告了旧格式（见Migrator），调用方应写回重新编码的值
+func (g *Group) decode(key string, stored ByteView) (value ByteView, outdated bool, err error) {
+	b := stored.b
+	for i := len(g.transformers) - 1; i >= 0; i-- {
+		t := g.transformers[i]
+		if m, ok := t.(Migrator); ok && m.Outdated(b) {
+			outdated = true
+		}
+		if b, err = t.Decode(key, b); err != nil {
+			return ByteView{}, false, err
+		}
+	}
+	stored.b = b
+	return stored, outdated, nil
+}
+
+// decodeHit 解码缓存c中命中的值
+// 解码失败时删除该条目并返回未命中；旧格式的值迁移后写回c
+func (g *Group) decodeHit(key string, stored ByteView, ok bool, c *shardedCache) (ByteView, bool) {
+	if !ok || len(g.transformers) == 0 {
+		return stored, ok
+	}
+	v, outdated, err := g.decode(key, stored)
+	if err != nil {
+		g.logger().Warn("decoding value", "group", g.name, "key", key, "err", err)
+		g.cacheFailed(err)
+		c.remove(key)
+		return ByteView{}, false
+	}
+	if outdated {
+		g.rewrite(c, key, stored, v)
+	}
+	return v, true
+}
+
+// errChanged 写回迁移结果时条目已被并发修改
+var errChanged = errors.New("entry changed")
+
+// rewrite 以当前格式重新编码迁移后的值并写回c
+// 条目在此期间被修改时放弃写回，不覆盖更新的值
+func (g *Group) rewrite(c *shardedCache, key string, stored, value ByteView) {
+	enc, err := g.encode(key, value)
+	if err != nil {
+		g.logger().Warn("re-encoding value", "group", g.name, "key", key, "err", err)
+		g.cacheFailed(err)
+		return
+	}
+	c.update(key, func(cur ByteView, ok bool) (ByteView, error) {
+		if !ok || !bytes.Equal(cur.b, stored.b) {
+			return ByteView{}, errChanged
+		}
+		return enc, nil
+	})
+}
+
+// getMain 读取mainCache并解码，直连数据源模式下总是未命中
+func (g *Group) getMain(key string) (ByteView, bool) {
+	if g.bypassing() {
+		return ByteView{}, false
+	}
+	v, ok := g.mainCache.get(key)
+	return g.decodeHit(key, v, ok, g.mainCache)
+}
+
+// addHot 编码后写入hotCache，超过长度上限的值不写入
+func (g *Group) addHot(key string, value ByteView) {
+	if g.bypassPopulate(key) {
+		return
+	}
+	enc, err := g.encode(key, value)
+	if err != nil {
+		g.logger().Warn("encoding value", "group", g.name, "key", key, "err", err)
+		g.cacheFailed(err)
+		return
+	}
+	if !g.oversized(enc) {
+		g.hotCache.add(key, enc)
+		g.waiters.notify(key)
+	}
+}
+
+// updateMain 在mainCache上原子地读-改-写，fn看到和返回的都是原始值；成功时唤醒等待该key的Wait
+// 配置了L2时，mainCache未命中则以L2中（或尚待写回L2）的值为旧值，写入成功后删除L2副本；
+// 整个过程持有l2Locks，期间L2中的副本不会被并发写回或删除
+func (g *Group) updateMain(key string, fn func(old ByteView, ok bool) (ByteView, error)) error {
+	if g.bypassing() {
+		return ErrCacheBypassed
+	}
+	var (
+		fromL2 ByteView
+		inL2   bool
+	)
+	if g.l2 != nil {
+		defer g.l2Locks.lock(key)()
+		fromL2, inL2 = g.getL2(key) // 磁盘读取放在分片锁之外
+	}
+	err := g.mainCache.update(key, func(stored ByteView, ok bool) (ByteView, error) {
+		if !ok && g.l2 != nil {
+			if stored, ok = g.pendingL2(key); !ok {
+				stored, ok = fromL2, inL2
+			}
+		}
+		if len(g.transformers) == 0 {
+			return fn(stored, ok)
+		}
+		old := stored
+		if ok {
+			var err error
+			if old, _, err = g.decode(key, stored); err != nil {
+				return ByteView{}, err
+			}
+		}
+		value, err := fn(old, ok)
+		if err != nil {
+			return ByteView{}, err
+		}
+		return g.encode(key, value)
+	})
+	if err != nil {
+		return err
+	}
+	if g.l2 != nil {
+		g.removeL2(key)
+	}
+	g.waiters.notify(key)
+	return nil
+}
@@ -0,0 +1,142 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+)
+
+// FallbackPeer combines two transports to the same peer. Requests go through
+// the primary transport (e.g. gRPC) and are retried on the fallback (e.g. HTTP)
+// only when the primary is unavailable, which eases incremental rollouts of a
+// new transport. The transport that served each request is counted per name.
+type FallbackPeer struct {
+	primaryName  string
+	primary      PeerGetter
+	fallbackName string
+	fallback     PeerGetter
+
+	// Unavailable reports whether err means the primary transport could not
+	// reach the peer at all. Only such errors trigger the fallback, so that a
+	// non-idempotent operation (Increment, Append) is never applied twice.
+	// Defaults to dial failures.
+	Unavailable func(err error) bool
+
+	mu     sync.Mutex
+	served map[string]int64
+}
+
+// NewFallbackPeer returns a PeerGetter that prefers primary and falls back to fallback.
+func NewFallbackPeer(primaryName string, primary PeerGetter, fallbackName string, fallback PeerGetter) *FallbackPeer {
+	return &FallbackPeer{
+		primaryName:  primaryName,
+		primary:      primary,
+		fallbackName: fallbackName,
+		fallback:     fallback,
+		Unavailable:  isDialError,
+		served:       make(map[string]int64),
+	}
+}
+
+// isDialError reports whether err happened while establishing a connection.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// Served returns how many requests each transport has served.
+func (f *FallbackPeer) Served() map[string]int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	served := make(map[string]int64, len(f.served))
+	for name, n := range f.served {
+		served[name] = n
+	}
+	return served
+}
+
+// try runs fn on the primary transport, and on the fallback if the primary is unavailable.
+func (f *FallbackPeer) try(fn func(PeerGetter) error) error {
+	name := f.primaryName
+	err := fn(f.primary)
+	if err != nil && f.Unavailable(err) {
+		slog.Warn("transport unavailable, falling back", "primary", f.primaryName, "fallback", f.fallbackName, "err", err)
+		name = f.fallbackName
+		err = fn(f.fallback)
+	}
+	if err == nil {
+		f.mu.Lock()
+		f.served[name]++
+		f.mu.Unlock()
+	}
+	return err
+}
+
+func (f *FallbackPeer) Get(ctx context.Context, group string, key string) (value []byte, err error) {
+	err = f.try(func(p PeerGetter) (err error) {
+		value, err = p.Get(ctx, group, key)
+		return err
+	})
+	return value, err
+}
+
+func (f *FallbackPeer) GetMulti(ctx context.Context, group string, keys []string) (values map[string][]byte, err error) {
+	err = f.try(func(p PeerGetter) (err error) {
+		values, err = p.GetMulti(ctx, group, keys)
+		return err
+	})
+	return values, err
+}
+
+func (f *FallbackPeer) Increment(ctx context.Context, group string, key string, delta int64) (n int64, err error) {
+	err = f.try(func(p PeerGetter) (err error) {
+		n, err = p.Increment(ctx, group, key, delta)
+		return err
+	})
+	return n, err
+}
+
+func (f *FallbackPeer) Append(ctx context.Context, group string, key string, data []byte) (n int, err error) {
+	err = f.try(func(p PeerGetter) (err error) {
+		n, err = p.Append(ctx, group, key, data)
+		return err
+	})
+	return n, err
+}
+
+func (f *FallbackPeer) CAS(ctx context.Context, group string, key string, old, new []byte) (swapped bool, err error) {
+	err = f.try(func(p PeerGetter) (err error) {
+		swapped, err = p.CAS(ctx, group, key, old, new)
+		return err
+	})
+	return swapped, err
+}
+
+func (f *FallbackPeer) Remove(ctx context.Context, group string, key string) error {
+	return f.try(func(p PeerGetter) error {
+		return p.Remove(ctx, group, key)
+	})
+}
+
+// RemoveWithReplay forwards to a transport implementing ReplayRemover. A
+// transport without it removes now and leaves the replay to the caller.
+func (f *FallbackPeer) RemoveWithReplay(ctx context.Context, group string, key string, delay time.Duration) error {
+	return f.try(func(p PeerGetter) error {
+		if r, ok := p.(ReplayRemover); ok {
+			return r.RemoveWithReplay(ctx, group, key, delay)
+		}
+		if err := p.Remove(ctx, group, key); err != nil {
+			return err
+		}
+		time.AfterFunc(delay, func() { p.Remove(context.Background(), group, key) })
+		return nil
+	})
+}
+
+var (
+	_ PeerGetter    = (*FallbackPeer)(nil)
+	_ ReplayRemover = (*FallbackPeer)(nil)
+)
@@ -0,0 +1,91 @@
+package geecache
+
+import (
+	"github/lhh-gh/geecache/lru"
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// adaptiveTTL 根据key的实际变化频率调整回源值的有效期
+// 每次回源时将新值的摘要与上一次回源的摘要比较：
+//   - 未变化：说明key稳定，有效期翻倍（不超过max）
+//   - 已变化：说明key易变，有效期减半（不低于min）
+//
+// 首次加载的key使用min。状态保存在独立的LRU中，容量有限，
+// 被淘汰的key下次加载时重新从min开始
+type adaptiveTTL struct {
+	min, max time.Duration
+
+	mu     sync.Mutex
+	states *lru.Cache
+}
+
+// ttlState 单个key的变化跟踪状态
+type ttlState struct {
+	sum uint64        // 上一次回源值的摘要
+	ttl time.Duration // 当前有效期
+}
+
+func (s *ttlState) Len() int { return 16 }
+
+// minAdaptiveStateBytes 状态LRU的最小容量
+// 按容量比例计算的预算为0时lru.New表示不限容量，状态会随key的数量无限增长
+const minAdaptiveStateBytes = 4 << 10
+
+func newAdaptiveTTL(min, max time.Duration, maxBytes int64) *adaptiveTTL {
+	if maxBytes < minAdaptiveStateBytes {
+		maxBytes = minAdaptiveStateBytes
+	}
+	return &adaptiveTTL{min: min, max: max, states: lru.New(maxBytes, nil)}
+}
+
+// next 记录key本次回源的值并返回其有效期
+func (a *adaptiveTTL) next(key string, value []byte) time.Duration {
+	h := fnv.New64a()
+	h.Write(value)
+	sum := h.Sum64()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	v, ok := a.states.Get(key)
+	if !ok {
+		a.states.Add(key, &ttlState{sum: sum, ttl: a.min})
+		return a.min
+	}
+	s := v.(*ttlState)
+	if s.sum == sum {
+		s.ttl *= 2
+		if s.ttl > a.max {
+			s.ttl = a.max
+		}
+	} else {
+		s.ttl /= 2
+		if s.ttl < a.min {
+			s.ttl = a.min
+		}
+	}
+	s.sum = sum
+	return s.ttl
+}
+
+// WithAdaptiveTTL 为回源加载的值启用自适应有效期
+// 值在有效期到期后重新回源，稳定的key有效期逐步延长到max，
+// 频繁变化的key逐步缩短到min。Set/SetWithTTL写入的值不受影响。
+// min<=0 或 max<min 时不启用
+func WithAdaptiveTTL(min, max time.Duration) GroupOption {
+	return func(g *Group) {
+		if min > 0 && max >= min {
+			g.adaptive = newAdaptiveTTL(min, max, g.cacheBytes/defaultNegCacheRatio)
+		}
+	}
+}
+
+// loadedView 为回源得到的数据构造缓存视图（深拷贝），按配置设置有效期
+func (g *Group) loadedView(key string, b []byte) ByteView {
+	value := ByteView{b: cloneBytes(b), src: SourceLoad}
+	if g.adaptive != nil {
+		value.e = time.Now().Add(g.adaptive.next(key, b))
+	}
+	return value
+}
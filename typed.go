@@ -0,0 +1,113 @@
+package geecache
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"reflect"
+
+	"github.com/golang/protobuf/proto"
+)
+
+// Codec 在类型T的值与缓存中存储的字节之间转换
+type Codec[T any] interface {
+	Marshal(v T) ([]byte, error)
+	Unmarshal(data []byte) (T, error)
+}
+
+// JSONCodec 以encoding/json编解码T
+type JSONCodec[T any] struct{}
+
+func (JSONCodec[T]) Marshal(v T) ([]byte, error) { return json.Marshal(v) }
+
+func (JSONCodec[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	err := json.Unmarshal(data, &v)
+	return v, err
+}
+
+// GobCodec 以encoding/gob编解码T（每个值独立编码，都带有类型描述）
+type GobCodec[T any] struct{}
+
+func (GobCodec[T]) Marshal(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec[T]) Unmarshal(data []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// ProtoCodec 以protobuf编解码T，T为生成的消息指针类型（如*pb.Response）
+type ProtoCodec[T proto.Message] struct{}
+
+func (ProtoCodec[T]) Marshal(v T) ([]byte, error) { return proto.Marshal(v) }
+
+func (ProtoCodec[T]) Unmarshal(data []byte) (T, error) {
+	var zero T
+	v := reflect.New(reflect.TypeOf(zero).Elem()).Interface().(T)
+	if err := proto.Unmarshal(data, v); err != nil {
+		return zero, err
+	}
+	return v, nil
+}
+
+// TypedGroup Group的类型化封装：读写时经codec在T与字节之间转换，
+// 调用方无需在每个调用点手动解码ByteView
+// 缓存、节点间传输的仍是编码后的字节，所有节点须使用相同的codec
+//
+// 典型用法：
+//
+//	users := NewTypedGroup(NewGroup("users", 64<<20,
+//		TypedGetterFunc(JSONCodec[User]{}, loadUser)), JSONCodec[User]{})
+//	u, err := users.Get(ctx, "42")
+type TypedGroup[T any] struct {
+	group *Group
+	codec Codec[T]
+}
+
+// NewTypedGroup 以codec封装g
+func NewTypedGroup[T any](g *Group, codec Codec[T]) *TypedGroup[T] {
+	return &TypedGroup[T]{group: g, codec: codec}
+}
+
+// Group 返回底层的Group，用于统计、删除等与值类型无关的操作
+func (t *TypedGroup[T]) Group() *Group {
+	return t.group
+}
+
+// Get 读取key并解码，语义同Group.GetContext
+func (t *TypedGroup[T]) Get(ctx context.Context, key string) (T, error) {
+	v, err := t.group.GetContext(ctx, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return t.codec.Unmarshal(v.b)
+}
+
+// Set 编码v后写入，语义同Group.Set
+func (t *TypedGroup[T]) Set(key string, v T) error {
+	b, err := t.codec.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return t.group.Set(key, b)
+}
+
+// TypedGetterFunc 将返回T的加载函数适配为Getter，结果经codec编码后缓存
+func TypedGetterFunc[T any](codec Codec[T], fn func(ctx context.Context, key string) (T, error)) ContextGetterFunc {
+	return func(ctx context.Context, key string) ([]byte, error) {
+		v, err := fn(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		return codec.Marshal(v)
+	}
+}
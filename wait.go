@@ -0,0 +1,87 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// keyWaiters 等待key写入的goroutine登记表
+// 写入路径在没有等待者时只做一次原子读，不加锁
+type keyWaiters struct {
+	n  atomic.Int64 // 登记中的等待者总数
+	mu sync.Mutex
+	m  map[string]*keyWaiter
+}
+
+type keyWaiter struct {
+	ch   chan struct{} // key写入时关闭
+	refs int
+}
+
+// add 登记一个等待者，返回写入时关闭的channel与注销函数
+func (w *keyWaiters) add(key string) (<-chan struct{}, func()) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.m == nil {
+		w.m = make(map[string]*keyWaiter)
+	}
+	kw := w.m[key]
+	if kw == nil {
+		kw = &keyWaiter{ch: make(chan struct{})}
+		w.m[key] = kw
+	}
+	kw.refs++
+	w.n.Add(1)
+	return kw.ch, func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		w.n.Add(-1)
+		if kw.refs--; kw.refs == 0 && w.m[key] == kw {
+			delete(w.m, key)
+		}
+	}
+}
+
+// notify 唤醒等待key的所有goroutine
+func (w *keyWaiters) notify(key string) {
+	if w.n.Load() == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if kw := w.m[key]; kw != nil {
+		close(kw.ch)
+		delete(w.m, key)
+	}
+}
+
+// Wait 阻塞直到key出现在本节点缓存中（由其他请求加载、Set写入或其他节点推送），
+// 或ctx结束；key已存在时立即返回
+// 适用场景：经缓存交接数据的生产者/消费者，消费者无需轮询
+//
+// 注意：
+//   - Wait不会触发回源加载
+//   - 只观察本节点的mainCache与hotCache，分布式模式下应在key的所属节点上等待
+func (g *Group) Wait(ctx context.Context, key string) (ByteView, error) {
+	if key == "" {
+		return ByteView{}, fmt.Errorf("key is required")
+	}
+	for {
+		// 先登记再检查，避免检查与登记之间的写入被错过
+		ch, done := g.waiters.add(key)
+		v, ok := g.lookupCache(key)
+		if ok {
+			done()
+			return v, nil
+		}
+		select {
+		case <-ch:
+			done()
+		case <-ctx.Done():
+			done()
+			return ByteView{}, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package geecache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Warm 加载keys并写入缓存，用于服务启动后、接收流量前预热热点key
+// 行为：
+//  1. 重复的key只加载一次；与并发的Get经singleflight合并
+//  2. 最多concurrency个key同时加载（<1时按1处理），回源受回源预算约束
+//  3. 已在缓存中的key不会重新加载；属于其他节点的key向所属节点获取
+//
+// 返回各key加载错误的合并；ctx取消后不再开始新的加载，并返回ctx的错误
+func (g *Group) Warm(ctx context.Context, keys []string, concurrency int) error {
+	concurrency = max(concurrency, 1)
+	seen := make(map[string]struct{}, len(keys))
+	work := make(chan string)
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for key := range work {
+				if _, err := g.GetContext(ctx, key); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("warming %s: %w", key, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		select {
+		case work <- key:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,73 @@
+package geecache
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// Setter 数据源写入接口，与Getter对称
+type Setter interface {
+	Set(ctx context.Context, key string, value []byte) error
+}
+
+// SetterFunc 函数类型适配器，实现Setter接口
+type SetterFunc func(ctx context.Context, key string, value []byte) error
+
+// Set 实现Setter接口方法
+func (f SetterFunc) Set(ctx context.Context, key string, value []byte) error {
+	return f(ctx, key, value)
+}
+
+// WithSetter 开启写穿透：Set先写入数据源s，成功后再写入缓存，Group即成为读写缓存
+// 保证：
+//  1. 数据源写入失败时返回其错误，缓存不变
+//  2. 同一key的并发Set按写入数据源的顺序写入缓存，缓存不会停留在较旧的值
+//  3. 与之竞争的加载不会用写入前读到的旧值覆盖新值
+//  4. 分布式模式下，写入数据源后按Remove的流程失效所属节点与其他节点的副本，
+//     之后的读取由所属节点从数据源重新加载；失效失败时返回错误（数据源已写入，可重试Set）
+//
+// 写入期间持有key所在分段（共64段）的写锁，同一分段的其他写入需等待：
+// 每个失效请求受WithPeerTimeout约束，数据源写入则只受调用方ctx约束，
+// 数据源可能挂起时应使用SetWithTTLContext并设置截止时间
+func WithSetter(s Setter) GroupOption {
+	return func(g *Group) {
+		g.setter = s
+	}
+}
+
+// writeLockStripes 写穿透按key散列使用的锁数量
+const writeLockStripes = 64
+
+// writeLocks 按key散列的锁，串行化同一key的写操作
+type writeLocks [writeLockStripes]sync.Mutex
+
+// lock 锁定key所在的分段，返回解锁函数
+func (l *writeLocks) lock(key string) func() {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	mu := &l[h.Sum32()%writeLockStripes]
+	mu.Lock()
+	return mu.Unlock
+}
+
+// writeThrough 将value写入数据源，失效集群内的旧副本后以view更新本节点缓存
+// 调用方保证g.setter非nil
+func (g *Group) writeThrough(ctx context.Context, key string, value []byte, view ByteView) error {
+	defer g.writeLocks.lock(key)()
+	if err := g.setter.Set(ctx, key, value); err != nil {
+		return err
+	}
+	err := g.removeEverywhere(key, func(_ context.Context, peer PeerGetter) error {
+		_, err := g.withPeerTimeout(ctx, func(ctx context.Context) ([]byte, error) {
+			return nil, peer.Remove(ctx, g.name, key)
+		})
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("invalidating peers after write: %w", err)
+	}
+	g.populateCache(key, view)
+	return nil
+}